@@ -0,0 +1,221 @@
+package haproxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// AddrChange is the result of set server addr as reported by HA-Proxy
+type AddrChange struct {
+	AddrChanged bool   // false when HA-Proxy had no need to change the address
+	OldAddr     string // address before the change, empty when not changed
+	NewAddr     string // address after the change, empty when not changed
+	PortChanged bool   // false when HA-Proxy had no need to change the port or no port was given
+	OldPort     string // port before the change, prefixed with + for servers mapping ports
+	NewPort     string // port after the change, prefixed with + for servers mapping ports
+}
+
+// change the address and port of a server using set server <backend>/<server> addr <ip> port <port>.
+// A port of 0 keeps the port of the server. The change is verified with show servers state
+func (rc *RuntimeClient) SetServerAddr(backend, server, ip string, port int) (*AddrChange, error) {
+	return rc.SetServerAddrContext(context.Background(), backend, server, ip, port)
+}
+
+// change the address and port of a server bound to the context
+func (rc *RuntimeClient) SetServerAddrContext(ctx context.Context, backend, server, ip string, port int) (*AddrChange, error) {
+	return setServerAddr(ctx, rc, backend, server, ip, port)
+}
+
+func setServerAddr(ctx context.Context, ex executor, backend, server, ip string, port int) (*AddrChange, error) {
+	if net.ParseIP(ip) == nil {
+		return nil, fmt.Errorf("invalid server address: %q", ip)
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid server port: %d", port)
+	}
+
+	command := fmt.Sprintf("set server %s/%s addr %s", backend, server, ip)
+	if port > 0 {
+		command += fmt.Sprintf(" port %d", port)
+	}
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(command, resp); err != nil {
+		return nil, err
+	}
+	change, err := parseAddrChange(command, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return nil, err
+	}
+	if !sameAddr(s.SrvAddr, ip) || (port > 0 && strings.TrimPrefix(s.SrvPort, "+") != strconv.Itoa(port)) {
+		return nil, &CommandError{
+			Command:  command,
+			Response: fmt.Sprintf("server state has srv_addr %s srv_port %s", s.SrvAddr, s.SrvPort),
+			Err:      ErrNotApplied,
+		}
+	}
+	return change, nil
+}
+
+// the response is a comma separated list of the changes to the address and the port followed
+// by the updater, e.g. IP changed from '172.24.21.40' to '172.24.21.50', no need to change
+// the port by 'stats socket command'
+func parseAddrChange(command string, resp []byte) (*AddrChange, error) {
+	text := strings.TrimSpace(string(resp))
+	unexpected := &CommandError{Command: command, Response: text, Err: ErrUnexpectedResponse}
+
+	change := &AddrChange{}
+	for _, part := range strings.Split(trimUpdater(text), ", ") {
+		switch {
+		case part == "no need to change the addr", part == "no need to change the port":
+		case strings.HasPrefix(part, "IP changed from "):
+			from, to, ok := changedFromTo(strings.TrimPrefix(part, "IP changed from "))
+			if !ok {
+				return nil, unexpected
+			}
+			change.AddrChanged, change.OldAddr, change.NewAddr = true, from, to
+		case strings.HasPrefix(part, "port changed from "):
+			from, to, ok := changedFromTo(strings.TrimPrefix(part, "port changed from "))
+			if !ok {
+				return nil, unexpected
+			}
+			change.PortChanged, change.OldPort, change.NewPort = true, from, to
+		default:
+			return nil, unexpected
+		}
+	}
+	return change, nil
+}
+
+// remove the updater HA-Proxy appends to the messages of changes, e.g. by 'stats socket command'
+func trimUpdater(text string) string {
+	i := strings.LastIndex(text, " by '")
+	if i < 0 || !strings.HasSuffix(text, "'") {
+		return text
+	}
+	return text[:i]
+}
+
+// split '<from>' to '<to>'
+func changedFromTo(s string) (string, string, bool) {
+	from, to, ok := strings.Cut(s, " to ")
+	if !ok {
+		return "", "", false
+	}
+	from, to = strings.Trim(from, "'"), strings.Trim(to, "'")
+	return from, to, from != "" && to != ""
+}
+
+// compare addresses by value as IPv6 addresses can be written in several ways
+func sameAddr(a, b string) bool {
+	ipa, ipb := net.ParseIP(a), net.ParseIP(b)
+	if ipa == nil || ipb == nil {
+		return a == b
+	}
+	return ipa.Equal(ipb)
+}
+
+// FQDNChange is the result of set server fqdn as reported by HA-Proxy
+type FQDNChange struct {
+	Changed bool   // false when HA-Proxy had no need to change the FQDN
+	OldFQDN string // FQDN before the change, empty when not changed or the server had none
+	NewFQDN string // FQDN after the change, empty when not changed
+}
+
+// change the FQDN of a server using set server <backend>/<server> fqdn <fqdn>. The backend
+// needs a resolvers section for HA-Proxy to accept it. The change is verified with show servers state
+func (rc *RuntimeClient) SetServerFQDN(backend, server, fqdn string) (*FQDNChange, error) {
+	return rc.SetServerFQDNContext(context.Background(), backend, server, fqdn)
+}
+
+// change the FQDN of a server bound to the context
+func (rc *RuntimeClient) SetServerFQDNContext(ctx context.Context, backend, server, fqdn string) (*FQDNChange, error) {
+	return setServerFQDN(ctx, rc, backend, server, fqdn)
+}
+
+func setServerFQDN(ctx context.Context, ex executor, backend, server, fqdn string) (*FQDNChange, error) {
+	if !validFQDN(fqdn) {
+		return nil, fmt.Errorf("invalid server fqdn: %q", fqdn)
+	}
+
+	command := fmt.Sprintf("set server %s/%s fqdn %s", backend, server, fqdn)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(command, resp); err != nil {
+		return nil, err
+	}
+	change, err := parseFQDNChange(command, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSuffix(s.SrvFQDN, "."), strings.TrimSuffix(fqdn, ".")) {
+		return nil, &CommandError{
+			Command:  command,
+			Response: fmt.Sprintf("server state has srv_fqdn %s", s.SrvFQDN),
+			Err:      ErrNotApplied,
+		}
+	}
+	return change, nil
+}
+
+// the response is the change of the FQDN followed by the updater, e.g. indexws/iws01 changed its
+// FQDN from (null) to iws01.example.com by 'stats socket command' or no need to change the FDQN
+// by 'stats socket command', where FDQN is spelled as by HA-Proxy
+func parseFQDNChange(command string, resp []byte) (*FQDNChange, error) {
+	text := strings.TrimSpace(string(resp))
+	unexpected := &CommandError{Command: command, Response: text, Err: ErrUnexpectedResponse}
+
+	msg := trimUpdater(text)
+	if msg == "no need to change the FDQN" || msg == "no need to change the FQDN" {
+		return &FQDNChange{}, nil
+	}
+	_, fromTo, ok := strings.Cut(msg, " changed its FQDN from ")
+	if !ok {
+		return nil, unexpected
+	}
+	from, to, ok := strings.Cut(fromTo, " to ")
+	if !ok || to == "" {
+		return nil, unexpected
+	}
+	if from == "(null)" {
+		from = ""
+	}
+	return &FQDNChange{Changed: true, OldFQDN: from, NewFQDN: to}, nil
+}
+
+// a host name of letters, digits and hyphens in labels of at most 63 characters
+func validFQDN(fqdn string) bool {
+	name := strings.TrimSuffix(fqdn, ".")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package haproxy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+)
+
+func TestParseAddrChange(t *testing.T) {
+	tests := []struct {
+		response string
+		change   AddrChange
+	}{
+		{"IP changed from '172.24.21.40' to '172.24.21.50', port changed from '8080' to '8081' by 'stats socket command'\n",
+			AddrChange{AddrChanged: true, OldAddr: "172.24.21.40", NewAddr: "172.24.21.50", PortChanged: true, OldPort: "8080", NewPort: "8081"}},
+		{"IP changed from '172.24.21.40' to '172.24.21.50', no need to change the port\n",
+			AddrChange{AddrChanged: true, OldAddr: "172.24.21.40", NewAddr: "172.24.21.50"}},
+		{"no need to change the addr, port changed from '+8080' to '+8081'\n",
+			AddrChange{PortChanged: true, OldPort: "+8080", NewPort: "+8081"}},
+		{"no need to change the addr by 'stats socket command'\n", AddrChange{}},
+		{"no need to change the addr\n", AddrChange{}},
+	}
+	for _, test := range tests {
+		change, err := parseAddrChange("cmd", []byte(test.response))
+		if err != nil {
+			t.Fatalf("unable to parse %q: %v", test.response, err)
+		}
+		if !reflect.DeepEqual(*change, test.change) {
+			t.Fatalf("unexpected change for %q: %+v", test.response, *change)
+		}
+	}
+
+	for _, response := range []string{"", "IP changed\n", "something else\n"} {
+		if _, err := parseAddrChange("cmd", []byte(response)); !errors.Is(err, ErrUnexpectedResponse) {
+			t.Fatalf("expected unexpected response for %q got: %v", response, err)
+		}
+	}
+}
+
+func TestSetServerAddr(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	change, err := client.SetServerAddr("indexws", "iws01", "172.24.21.50", 8081)
+	if err != nil {
+		t.Fatalf("set server addr failed: %v", err)
+	}
+	expected := AddrChange{AddrChanged: true, OldAddr: "172.24.21.40", NewAddr: "172.24.21.50", PortChanged: true, OldPort: "8080", NewPort: "8081"}
+	if !reflect.DeepEqual(*change, expected) {
+		t.Fatalf("unexpected change: %+v", *change)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.Addr != "172.24.21.50" || fake.Port != 8081 {
+		t.Fatalf("server not changed: %s:%d", fake.Addr, fake.Port)
+	}
+
+	// the same address again and without a port
+	change, err = client.SetServerAddr("indexws", "iws01", "172.24.21.50", 0)
+	if err != nil {
+		t.Fatalf("set server addr failed: %v", err)
+	}
+	if change.AddrChanged || change.PortChanged {
+		t.Fatalf("unexpected change: %+v", *change)
+	}
+}
+
+func TestSetServerAddrNotApplied(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.Handle("set server indexws/iws01 addr 172.24.21.50 port 8080",
+		"IP changed from '172.24.21.40' to '172.24.21.50', no need to change the port by 'stats socket command'")
+
+	_, err := client.SetServerAddr("indexws", "iws01", "172.24.21.50", 8080)
+	if !errors.Is(err, ErrNotApplied) {
+		t.Fatalf("expected not applied got: %v", err)
+	}
+}
+
+func TestSetServerAddrInvalid(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	if _, err := client.SetServerAddr("indexws", "iws01", "not-an-ip", 8080); err == nil {
+		t.Fatalf("expected error for invalid address")
+	}
+	if _, err := client.SetServerAddr("indexws", "iws01", "172.24.21.50", 70000); err == nil {
+		t.Fatalf("expected error for invalid port")
+	}
+	if len(srv.Commands()) != 0 {
+		t.Fatalf("invalid input sent: %v", srv.Commands())
+	}
+	if _, err := client.SetServerAddr("indexws", "nope", "172.24.21.50", 8080); !errors.Is(err, ErrUnknownServer) {
+		t.Fatalf("expected unknown server got: %v", err)
+	}
+}
+
+func TestParseFQDNChange(t *testing.T) {
+	tests := []struct {
+		response string
+		change   FQDNChange
+	}{
+		{"indexws/iws01 changed its FQDN from (null) to iws01.example.com by 'stats socket command'\n",
+			FQDNChange{Changed: true, NewFQDN: "iws01.example.com"}},
+		{"indexws/iws01 changed its FQDN from iws01.blue.example.com to iws01.green.example.com by 'stats socket command'\n",
+			FQDNChange{Changed: true, OldFQDN: "iws01.blue.example.com", NewFQDN: "iws01.green.example.com"}},
+		{"no need to change the FDQN by 'stats socket command'\n", FQDNChange{}},
+	}
+	for _, test := range tests {
+		change, err := parseFQDNChange("cmd", []byte(test.response))
+		if err != nil {
+			t.Fatalf("unable to parse %q: %v", test.response, err)
+		}
+		if !reflect.DeepEqual(*change, test.change) {
+			t.Fatalf("unexpected change for %q: %+v", test.response, *change)
+		}
+	}
+
+	for _, response := range []string{"", "\n", "indexws/iws01 changed its FQDN\n", "something else\n"} {
+		if _, err := parseFQDNChange("cmd", []byte(response)); !errors.Is(err, ErrUnexpectedResponse) {
+			t.Fatalf("expected unexpected response for %q got: %v", response, err)
+		}
+	}
+}
+
+func TestSetServerFQDN(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	change, err := client.SetServerFQDN("indexws", "iws01", "iws01.green.example.com")
+	if err != nil {
+		t.Fatalf("set server fqdn failed: %v", err)
+	}
+	if !change.Changed || change.OldFQDN != "" || change.NewFQDN != "iws01.green.example.com" {
+		t.Fatalf("unexpected change: %+v", *change)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.FQDN != "iws01.green.example.com" {
+		t.Fatalf("fqdn not changed: %s", fake.FQDN)
+	}
+
+	change, err = client.SetServerFQDN("indexws", "iws01", "iws01.green.example.com")
+	if err != nil {
+		t.Fatalf("set server fqdn failed: %v", err)
+	}
+	if change.Changed {
+		t.Fatalf("unexpected change: %+v", *change)
+	}
+
+	for _, fqdn := range []string{"", "bad name", "-bad.example.com", "a..b"} {
+		if _, err := client.SetServerFQDN("indexws", "iws01", fqdn); err == nil {
+			t.Fatalf("expected error for fqdn %q", fqdn)
+		}
+	}
+}
+
+func TestSetServerFQDNNotApplied(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.Handle("set server indexws/iws01 fqdn iws01.green.example.com",
+		"indexws/iws01 changed its FQDN from (null) to iws01.green.example.com by 'stats socket command'")
+
+	_, err := client.SetServerFQDN("indexws", "iws01", "iws01.green.example.com")
+	if !errors.Is(err, ErrNotApplied) {
+		t.Fatalf("expected not applied got: %v", err)
+	}
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.FQDN = "IWS01.green.example.com" })
+	if _, err := client.SetServerFQDN("indexws", "iws01", "iws01.green.example.com"); err != nil {
+		t.Fatalf("fqdn compared with case: %v", err)
+	}
+}
+
+func TestSetServerFQDNNoResolution(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.Handle("set server indexws/iws01 fqdn iws01.green.example.com",
+		"set server <b>/<s> fqdn failed because no resolution is configured.")
+
+	_, err := client.SetServerFQDN("indexws", "iws01", "iws01.green.example.com")
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument got: %v", err)
+	}
+}
@@ -0,0 +1,306 @@
+package haproxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/industria/haproxy-runtime-api-client/state"
+)
+
+// Check is the health check or the agent check of a server
+type Check string
+
+const (
+	CheckHealth Check = "health"
+	CheckAgent  Check = "agent"
+)
+
+// HealthState is the health of a server forced with set server health
+type HealthState string
+
+const (
+	HealthUp       HealthState = "up"
+	HealthStopping HealthState = "stopping"
+	HealthDown     HealthState = "down"
+)
+
+// AgentState is the state of the agent of a server forced with set server agent
+type AgentState string
+
+const (
+	AgentUp   AgentState = "up"
+	AgentDown AgentState = "down"
+)
+
+// enable a check of a server using enable health|agent <backend>/<server>. The check must be
+// configured on the server. The change is verified with the check flags of show servers state
+func (rc *RuntimeClient) EnableCheck(backend, server string, check Check) error {
+	return rc.EnableCheckContext(context.Background(), backend, server, check)
+}
+
+// enable a check of a server bound to the context
+func (rc *RuntimeClient) EnableCheckContext(ctx context.Context, backend, server string, check Check) error {
+	return setCheckEnabled(ctx, rc, backend, server, check, true)
+}
+
+// disable a check of a server using disable health|agent <backend>/<server>, e.g. to pause
+// checks during a maintenance. The change is verified with the check flags of show servers state
+func (rc *RuntimeClient) DisableCheck(backend, server string, check Check) error {
+	return rc.DisableCheckContext(context.Background(), backend, server, check)
+}
+
+// disable a check of a server bound to the context
+func (rc *RuntimeClient) DisableCheckContext(ctx context.Context, backend, server string, check Check) error {
+	return setCheckEnabled(ctx, rc, backend, server, check, false)
+}
+
+func setCheckEnabled(ctx context.Context, ex executor, backend, server string, check Check, enabled bool) error {
+	if check != CheckHealth && check != CheckAgent {
+		return fmt.Errorf("unknown check: %s", check)
+	}
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	command := fmt.Sprintf("%s %s %s/%s", action, check, backend, server)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	if err := emptyResponse(command, resp); err != nil {
+		return err
+	}
+
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return err
+	}
+	flags := s.SrvCheckState
+	if check == CheckAgent {
+		flags = s.SrvAgentState
+	}
+	if (flags&state.CheckStateEnabled != 0) != enabled {
+		return &CommandError{
+			Command:  command,
+			Response: fmt.Sprintf("server state has %s check state 0x%02x", check, uint(flags)),
+			Err:      ErrNotApplied,
+		}
+	}
+	return nil
+}
+
+// force the health of a server using set server <backend>/<server> health up|stopping|down.
+// The health is verified with the operational state of show servers state, and is changed
+// again by the next health check when checks are enabled
+func (rc *RuntimeClient) SetServerHealth(backend, server string, health HealthState) error {
+	return rc.SetServerHealthContext(context.Background(), backend, server, health)
+}
+
+// force the health of a server bound to the context
+func (rc *RuntimeClient) SetServerHealthContext(ctx context.Context, backend, server string, health HealthState) error {
+	return setServerHealth(ctx, rc, backend, server, health)
+}
+
+func setServerHealth(ctx context.Context, ex executor, backend, server string, health HealthState) error {
+	var expected func(state.OperationalState) bool
+	switch health {
+	case HealthUp:
+		expected = func(op state.OperationalState) bool {
+			return op == state.OperationalStateRunning || op == state.OperationalStateStarting
+		}
+	case HealthStopping:
+		expected = func(op state.OperationalState) bool { return op == state.OperationalStateStopping }
+	case HealthDown:
+		expected = func(op state.OperationalState) bool { return op == state.OperationalStateStopped }
+	default:
+		return fmt.Errorf("unknown health: %s", health)
+	}
+
+	command := fmt.Sprintf("set server %s/%s health %s", backend, server, health)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	if err := emptyResponse(command, resp); err != nil {
+		return err
+	}
+
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return err
+	}
+	// a server in maintenance is stopped whatever its health
+	if s.SrvAdminState&state.AdminStateForcedMaintenance == 0 && !expected(s.SrvOpState) {
+		return &CommandError{
+			Command:  command,
+			Response: fmt.Sprintf("server state has operational state %d", s.SrvOpState),
+			Err:      ErrNotApplied,
+		}
+	}
+	return nil
+}
+
+// force the state of the agent of a server using set server <backend>/<server> agent up|down.
+// The agent check must be enabled, which is checked with the agent flags of show servers state
+func (rc *RuntimeClient) SetServerAgent(backend, server string, agent AgentState) error {
+	return rc.SetServerAgentContext(context.Background(), backend, server, agent)
+}
+
+// force the state of the agent of a server bound to the context
+func (rc *RuntimeClient) SetServerAgentContext(ctx context.Context, backend, server string, agent AgentState) error {
+	return setServerAgent(ctx, rc, backend, server, agent)
+}
+
+func setServerAgent(ctx context.Context, ex executor, backend, server string, agent AgentState) error {
+	if agent != AgentUp && agent != AgentDown {
+		return fmt.Errorf("unknown agent state: %s", agent)
+	}
+	command := fmt.Sprintf("set server %s/%s agent %s", backend, server, agent)
+
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return err
+	}
+	if s.SrvAgentState&state.CheckStateEnabled == 0 {
+		return &CommandError{
+			Command:  command,
+			Response: fmt.Sprintf("server state has agent check state 0x%02x", uint(s.SrvAgentState)),
+			Err:      ErrInvalidArgument,
+		}
+	}
+
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	return emptyResponse(command, resp)
+}
+
+// change the port of the health and agent checks of a server using
+// set server <backend>/<server> check-port <port>, verified with show servers state
+func (rc *RuntimeClient) SetServerCheckPort(backend, server string, port int) error {
+	return rc.SetServerCheckPortContext(context.Background(), backend, server, port)
+}
+
+// change the port of the checks of a server bound to the context
+func (rc *RuntimeClient) SetServerCheckPortContext(ctx context.Context, backend, server string, port int) error {
+	return setServerCheckPort(ctx, rc, backend, server, port)
+}
+
+func setServerCheckPort(ctx context.Context, ex executor, backend, server string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("invalid check port: %d", port)
+	}
+	command := fmt.Sprintf("set server %s/%s check-port %d", backend, server, port)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	if err := expectResponse(command, resp, "", "health check port updated."); err != nil {
+		return err
+	}
+
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return err
+	}
+	if s.SrvCheckPort != strconv.Itoa(port) {
+		return &CommandError{
+			Command:  command,
+			Response: fmt.Sprintf("server state has srv_check_port %s", s.SrvCheckPort),
+			Err:      ErrNotApplied,
+		}
+	}
+	return nil
+}
+
+// change the address of the health check of a server using set server <backend>/<server>
+// check-addr <ip> port <port>. A port of 0 keeps the port. The change is verified with show servers state
+func (rc *RuntimeClient) SetServerCheckAddr(backend, server, ip string, port int) error {
+	return rc.SetServerCheckAddrContext(context.Background(), backend, server, ip, port)
+}
+
+// change the address of the health check of a server bound to the context
+func (rc *RuntimeClient) SetServerCheckAddrContext(ctx context.Context, backend, server, ip string, port int) error {
+	return setCheckAddr(ctx, rc, backend, server, CheckHealth, ip, port)
+}
+
+// change the address of the agent check of a server using set server <backend>/<server>
+// agent-addr <ip> port <port>. A port of 0 keeps the port. The change is verified with show servers state
+func (rc *RuntimeClient) SetServerAgentAddr(backend, server, ip string, port int) error {
+	return rc.SetServerAgentAddrContext(context.Background(), backend, server, ip, port)
+}
+
+// change the address of the agent check of a server bound to the context
+func (rc *RuntimeClient) SetServerAgentAddrContext(ctx context.Context, backend, server, ip string, port int) error {
+	return setCheckAddr(ctx, rc, backend, server, CheckAgent, ip, port)
+}
+
+func setCheckAddr(ctx context.Context, ex executor, backend, server string, check Check, ip string, port int) error {
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("invalid %s check address: %q", check, ip)
+	}
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("invalid %s check port: %d", check, port)
+	}
+
+	setting, message := "check-addr", "health check addr updated."
+	if check == CheckAgent {
+		setting, message = "agent-addr", "agent addr updated."
+	}
+	command := fmt.Sprintf("set server %s/%s %s %s", backend, server, setting, ip)
+	if port > 0 {
+		command += fmt.Sprintf(" port %d", port)
+	}
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	if err := expectResponse(command, resp, "", message); err != nil {
+		return err
+	}
+
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return err
+	}
+	addr, statePort := s.SrvCheckAddr, s.SrvCheckPort
+	if check == CheckAgent {
+		addr, statePort = s.SrvAgentAddr, s.SrvAgentPort
+	}
+	if !sameAddr(addr, ip) || (port > 0 && statePort != strconv.Itoa(port)) {
+		return &CommandError{
+			Command:  command,
+			Response: fmt.Sprintf("server state has %s %s port %s", setting, addr, statePort),
+			Err:      ErrNotApplied,
+		}
+	}
+	return nil
+}
+
+// change the string sent to the agent of a server using set server <backend>/<server> agent-send <string>.
+// HA-Proxy only takes the first word of the string so strings with white space are rejected.
+// The string is not part of show servers state so only the response is checked
+func (rc *RuntimeClient) SetServerAgentSend(backend, server, send string) error {
+	return rc.SetServerAgentSendContext(context.Background(), backend, server, send)
+}
+
+// change the string sent to the agent of a server bound to the context
+func (rc *RuntimeClient) SetServerAgentSendContext(ctx context.Context, backend, server, send string) error {
+	return setServerAgentSend(ctx, rc, backend, server, send)
+}
+
+func setServerAgentSend(ctx context.Context, ex executor, backend, server, send string) error {
+	if send == "" || strings.ContainsAny(send, " \t\r\n;") {
+		return fmt.Errorf("invalid agent send string: %q", send)
+	}
+	command := fmt.Sprintf("set server %s/%s agent-send %s", backend, server, send)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	return expectResponse(command, resp, "", "agent send string updated.")
+}
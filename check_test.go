@@ -0,0 +1,150 @@
+package haproxy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+)
+
+func TestEnableDisableCheck(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Agent.Configured = true })
+
+	for _, check := range []Check{CheckHealth, CheckAgent} {
+		if err := client.DisableCheck("indexws", "iws01", check); err != nil {
+			t.Fatalf("disable %s failed: %v", check, err)
+		}
+		if err := client.EnableCheck("indexws", "iws01", check); err != nil {
+			t.Fatalf("enable %s failed: %v", check, err)
+		}
+	}
+	fake, _ := srv.LookupServer("indexws", "iws01")
+	if !fake.Health.Enabled || !fake.Agent.Enabled {
+		t.Fatalf("checks not enabled: %+v %+v", fake.Health, fake.Agent)
+	}
+
+	if err := client.EnableCheck("indexws", "iws02", CheckAgent); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument for agent not configured got: %v", err)
+	}
+	if err := client.EnableCheck("indexws", "iws01", "other"); err == nil {
+		t.Fatalf("expected error for unknown check")
+	}
+}
+
+func TestEnableCheckNotApplied(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.Handle("disable health indexws/iws01", "")
+
+	if err := client.DisableCheck("indexws", "iws01", CheckHealth); !errors.Is(err, ErrNotApplied) {
+		t.Fatalf("expected not applied got: %v", err)
+	}
+}
+
+func TestSetServerHealth(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	for _, health := range []HealthState{HealthDown, HealthStopping, HealthUp} {
+		if err := client.SetServerHealth("indexws", "iws01", health); err != nil {
+			t.Fatalf("set health %s failed: %v", health, err)
+		}
+		if fake, _ := srv.LookupServer("indexws", "iws01"); fake.Status != string(health) {
+			t.Fatalf("health not %s: %s", health, fake.Status)
+		}
+	}
+	if err := client.SetServerHealth("indexws", "iws01", "sideways"); err == nil {
+		t.Fatalf("expected error for unknown health")
+	}
+
+	srv.Handle("set server indexws/iws01 health down", "")
+	if err := client.SetServerHealth("indexws", "iws01", HealthDown); !errors.Is(err, ErrNotApplied) {
+		t.Fatalf("expected not applied got: %v", err)
+	}
+}
+
+func TestSetServerAgent(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	if err := client.SetServerAgent("indexws", "iws01", AgentDown); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument for agent not enabled got: %v", err)
+	}
+	for _, command := range srv.Commands() {
+		if command == "set server indexws/iws01 agent down" {
+			t.Fatalf("agent changed without an enabled agent check")
+		}
+	}
+
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) {
+		s.Agent = haproxytest.Check{Configured: true, Enabled: true}
+	})
+	if err := client.SetServerAgent("indexws", "iws01", AgentDown); err != nil {
+		t.Fatalf("set agent failed: %v", err)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.Status != "down" {
+		t.Fatalf("agent not down: %s", fake.Status)
+	}
+}
+
+func TestSetServerCheckPort(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	if err := client.SetServerCheckPort("indexws", "iws01", 8081); err != nil {
+		t.Fatalf("set check-port failed: %v", err)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.Health.Port != 8081 {
+		t.Fatalf("check port not changed: %d", fake.Health.Port)
+	}
+	if err := client.SetServerCheckPort("indexws", "iws01", 0); err == nil {
+		t.Fatalf("expected error for port 0")
+	}
+
+	srv.Handle("set server indexws/iws01 check-port 8082", "health check port updated.")
+	if err := client.SetServerCheckPort("indexws", "iws01", 8082); !errors.Is(err, ErrNotApplied) {
+		t.Fatalf("expected not applied got: %v", err)
+	}
+}
+
+func TestSetServerCheckAddr(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	if err := client.SetServerCheckAddr("indexws", "iws01", "172.24.22.40", 9000); err != nil {
+		t.Fatalf("set check-addr failed: %v", err)
+	}
+	if err := client.SetServerAgentAddr("indexws", "iws01", "172.24.22.41", 0); err != nil {
+		t.Fatalf("set agent-addr failed: %v", err)
+	}
+	fake, _ := srv.LookupServer("indexws", "iws01")
+	if fake.Health.Addr != "172.24.22.40" || fake.Health.Port != 9000 || fake.Agent.Addr != "172.24.22.41" {
+		t.Fatalf("check addresses not changed: %+v %+v", fake.Health, fake.Agent)
+	}
+
+	if err := client.SetServerCheckAddr("indexws", "iws01", "not-an-ip", 0); err == nil {
+		t.Fatalf("expected error for invalid address")
+	}
+	if err := client.SetServerAgentAddr("indexws", "nope", "172.24.22.41", 0); !errors.Is(err, ErrUnknownServer) {
+		t.Fatalf("expected unknown server got: %v", err)
+	}
+}
+
+func TestSetServerAgentSend(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Agent.Configured = true })
+
+	if err := client.SetServerAgentSend("indexws", "iws01", "status"); err != nil {
+		t.Fatalf("set agent-send failed: %v", err)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.Agent.Send != "status" {
+		t.Fatalf("agent send not changed: %s", fake.Agent.Send)
+	}
+	if err := client.SetServerAgentSend("indexws", "iws02", "status"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument got: %v", err)
+	}
+	for _, send := range []string{"", "a;b", "status ready", "status\tready", "status\n"} {
+		if err := client.SetServerAgentSend("indexws", "iws01", send); err == nil {
+			t.Fatalf("expected error for agent send string %q", send)
+		}
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.Agent.Send != "status" {
+		t.Fatalf("agent send changed by an invalid string: %s", fake.Agent.Send)
+	}
+}
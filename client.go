@@ -4,24 +4,40 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"strings"
 	"time"
 
+	"github.com/industria/haproxy-runtime-api-client/info"
 	"github.com/industria/haproxy-runtime-api-client/stat"
 	"github.com/industria/haproxy-runtime-api-client/state"
 )
 
+// executor is implemented by the types able to send a command to the Runtime API
+// and is used for sharing the typed commands between a RuntimeClient and a Session
+type executor interface {
+	ExecuteContext(ctx context.Context, command string) ([]byte, error)
+}
+
 type RuntimeClient struct {
-	network string
-	address string
+	network        string
+	address        string
+	logger         Logger
+	dialer         Dialer
+	dialTimeout    time.Duration
+	commandTimeout time.Duration
+	pollInterval   time.Duration
+	retry          RetryPolicy
+	accessLevel    AccessLevel
+	statFormat     StatFormat
+	pool           *pool // sessions used for the commands when a pool is enabled
 }
 
 // create new RuntimeClient for stats socket with address
 // where the address is expressed as a URI and can
 // be either unix://path or tcp://address:port
-func NewClient(uri string) (*RuntimeClient, error) {
+// the client is configured by the options applied in the order given
+func NewClient(uri string, opts ...Option) (*RuntimeClient, error) {
 	var network string
 	var address string
 	if strings.HasPrefix(uri, "unix://") {
@@ -31,41 +47,160 @@ func NewClient(uri string) (*RuntimeClient, error) {
 		network = "tcp"
 		address = uri[6:]
 	} else {
-		return nil, fmt.Errorf("address [%s] must start with unix:// or tcp://", address)
+		return nil, fmt.Errorf("address [%s] must start with unix:// or tcp://", uri)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("address [%s] is missing the path or address:port", uri)
 	}
 
-	return &RuntimeClient{
-		network: network,
-		address: address,
-	}, nil
+	rc := &RuntimeClient{
+		network:      network,
+		address:      address,
+		logger:       nopLogger{},
+		dialer:       &net.Dialer{},
+		pollInterval: time.Millisecond * 10,
+		retry:        RetryPolicy{Attempts: 1},
+		accessLevel:  AccessLevelAdmin,
+		statFormat:   StatFormatCSV,
+	}
+	for _, opt := range opts {
+		if err := opt(rc); err != nil {
+			return nil, err
+		}
+	}
+	return rc, nil
 }
 
 // execute a HA-Proxy runtime api command
 // https://cbonte.github.io/haproxy-dconv/2.5/management.html#9.3
 // result is the raw response from the API
 func (rc *RuntimeClient) Execute(command string) ([]byte, error) {
-	conn, err := net.Dial(rc.network, rc.address)
+	return rc.ExecuteContext(context.Background(), command)
+}
+
+// execute a HA-Proxy runtime api command bound to the context
+// the deadline of the context is applied to dialing, writing the command and reading the response
+// and cancelling the context aborts the command. result is the raw response from the API
+func (rc *RuntimeClient) ExecuteContext(ctx context.Context, command string) ([]byte, error) {
+	if rc.commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.commandTimeout)
+		defer cancel()
+	}
+
+	if rc.pool != nil {
+		return rc.pool.execute(ctx, command)
+	}
+
+	conn, err := rc.dial(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
 
+	stop := watchContext(ctx, conn)
+	defer stop()
+
+	// the access level only applies to the connection so it is lowered in front of the command
 	cmd := []byte(command + "\n")
+	if rc.accessLevel != AccessLevelAdmin {
+		cmd = []byte(string(rc.accessLevel) + "; " + command + "\n")
+	}
 
-	log.Printf("%s", cmd)
+	rc.logger.Debug("execute command", "command", commandLine(command))
 
 	if _, err := conn.Write(cmd); err != nil {
-		return nil, fmt.Errorf("unable to send command: %v", cmd)
+		return nil, contextError(ctx, fmt.Errorf("unable to send command: %w", err))
 	}
 
 	resp, err := io.ReadAll(conn)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read response: %v", err)
+		return nil, contextError(ctx, fmt.Errorf("unable to read response: %w", err))
 	}
 
 	return resp, nil
 }
 
+// connect to the stats socket retrying failed attempts according to the retry policy
+func (rc *RuntimeClient) dial(ctx context.Context) (net.Conn, error) {
+	backoff := rc.retry.Backoff
+	for attempt := 1; ; attempt++ {
+		conn, err := rc.dialOnce(ctx)
+		if err == nil || attempt >= rc.retry.Attempts || ctx.Err() != nil {
+			return conn, err
+		}
+		rc.logger.Debug("connecting failed - retrying", "attempt", attempt, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (rc *RuntimeClient) dialOnce(ctx context.Context) (net.Conn, error) {
+	if rc.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.dialTimeout)
+		defer cancel()
+	}
+	return rc.dialer.DialContext(ctx, rc.network, rc.address)
+}
+
+// apply the context deadline to the connection and interrupt blocked reads and writes
+// when the context is cancelled. the returned function must be called when the connection
+// is no longer used with the context
+func watchContext(ctx context.Context, conn net.Conn) func() {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			// a deadline in the past unblocks any pending read or write on the connection
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// report the context error in place of err when the context ended the operation
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	// the connection deadline can expire slightly before the context registers it
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return err
+}
+
+// the first line of a command, leaving out a payload sent after << as it can hold secrets like keys
+func commandLine(command string) string {
+	if i := strings.IndexByte(command, '\n'); i >= 0 {
+		return command[:i]
+	}
+	return command
+}
+
 type ServerState string
 
 const (
@@ -77,41 +212,156 @@ const (
 // execute a server state change command
 // set server <backend>/<server> state [ ready | drain | maint ]
 func (rc *RuntimeClient) SetServerState(backend, server string, state ServerState) error {
+	return rc.SetServerStateContext(context.Background(), backend, server, state)
+}
+
+// execute a server state change command bound to the context
+func (rc *RuntimeClient) SetServerStateContext(ctx context.Context, backend, server string, state ServerState) error {
+	return setServerState(ctx, rc, backend, server, state)
+}
+
+func setServerState(ctx context.Context, ex executor, backend, server string, state ServerState) error {
 	command := fmt.Sprintf("set server %s/%s state %s", backend, server, state)
-	resp, err := rc.Execute(command)
+	resp, err := ex.ExecuteContext(ctx, command)
 	if err != nil {
 		return err
 	}
 
 	// a response other than a line feed would be an error on these state changes
-	if len(resp) != 1 && resp[0] != 10 {
-		return fmt.Errorf("changing %s/%s to %s failed with: %s", backend, server, state, resp)
-	}
-
-	return nil
+	return emptyResponse(command, resp)
 }
 
 // get the server state for all backend
 // show servers state [<backend>]
 func (rc *RuntimeClient) ShowServersState() ([]state.ServerState, error) {
+	return rc.ShowServersStateContext(context.Background())
+}
+
+// get the server state for all backend bound to the context
+func (rc *RuntimeClient) ShowServersStateContext(ctx context.Context) ([]state.ServerState, error) {
+	return showServersState(ctx, rc, "")
+}
+
+// show servers state for a backend or all backends when the backend is empty
+func showServersState(ctx context.Context, ex executor, backend string) ([]state.ServerState, error) {
 	command := "show servers state"
-	resp, err := rc.Execute(command)
+	if backend != "" {
+		command += " " + backend
+	}
+	resp, err := ex.ExecuteContext(ctx, command)
 	if err != nil {
 		return nil, err
 	}
+	if err := responseError(command, resp); err != nil {
+		return nil, err
+	}
 	return state.ParseShowServersState(resp)
 }
 
 // get stat counters using the command show stat
-func (rc *RuntimeClient) ShowStat() ([]stat.StatCounters, error) {
-	command := "show stat"
-	resp, err := rc.Execute(command)
+// an optional filter limits the rows to a proxy, types of objects and a server
+func (rc *RuntimeClient) ShowStat(filter ...StatFilter) ([]stat.StatCounters, error) {
+	return rc.ShowStatContext(context.Background(), filter...)
+}
+
+// get stat counters using the command show stat bound to the context
+func (rc *RuntimeClient) ShowStatContext(ctx context.Context, filter ...StatFilter) ([]stat.StatCounters, error) {
+	return showStat(ctx, rc, rc.statFormat, filter...)
+}
+
+// get the typed stat fields using the command show stat json
+// the fields can be converted to counters with stat.TypedToStatCounters
+func (rc *RuntimeClient) ShowStatJSON(filter ...StatFilter) ([]stat.TypedField, error) {
+	return rc.ShowStatJSONContext(context.Background(), filter...)
+}
+
+// get the typed stat fields using the command show stat json bound to the context
+func (rc *RuntimeClient) ShowStatJSONContext(ctx context.Context, filter ...StatFilter) ([]stat.TypedField, error) {
+	return showStatFields(ctx, rc, StatFormatJSON, filter...)
+}
+
+// only the first filter is used, the filter is variadic for keeping it optional
+func showStat(ctx context.Context, ex executor, format StatFormat, filter ...StatFilter) ([]stat.StatCounters, error) {
+	if format == StatFormatTyped || format == StatFormatJSON {
+		fields, err := showStatFields(ctx, ex, format, filter...)
+		if err != nil {
+			return nil, err
+		}
+		return stat.TypedToStatCounters(fields)
+	}
+
+	command := statCommand(format, filter)
+	resp, err := ex.ExecuteContext(ctx, command)
 	if err != nil {
 		return nil, err
 	}
+	if err := responseError(command, resp); err != nil {
+		return nil, err
+	}
 	return stat.ParseShowStat(resp)
 }
 
+// show stat in the typed or json format
+func showStatFields(ctx context.Context, ex executor, format StatFormat, filter ...StatFilter) ([]stat.TypedField, error) {
+	command := statCommand(format, filter)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(command, resp); err != nil {
+		return nil, err
+	}
+	if format == StatFormatJSON {
+		return stat.ParseShowStatJSON(resp)
+	}
+	return stat.ParseShowStatTyped(resp)
+}
+
+// get the process information using the command show info
+func (rc *RuntimeClient) ShowInfo() (*info.Info, error) {
+	return rc.ShowInfoContext(context.Background())
+}
+
+// get the process information using the command show info bound to the context
+func (rc *RuntimeClient) ShowInfoContext(ctx context.Context) (*info.Info, error) {
+	return showInfo(ctx, rc)
+}
+
+func showInfo(ctx context.Context, ex executor) (*info.Info, error) {
+	command := "show info"
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(command, resp); err != nil {
+		return nil, err
+	}
+	return info.ParseShowInfo(resp)
+}
+
+// get the process information using the command show info json, which carries the type of
+// every field. The information returned is the same as from ShowInfo
+func (rc *RuntimeClient) ShowInfoJSON() (*info.Info, error) {
+	return rc.ShowInfoJSONContext(context.Background())
+}
+
+// get the process information using the command show info json bound to the context
+func (rc *RuntimeClient) ShowInfoJSONContext(ctx context.Context) (*info.Info, error) {
+	return showInfoJSON(ctx, rc)
+}
+
+func showInfoJSON(ctx context.Context, ex executor) (*info.Info, error) {
+	command := "show info json"
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(command, resp); err != nil {
+		return nil, err
+	}
+	return info.ParseShowInfoJSON(resp)
+}
+
 //	place server into maintenance state with a previous drain operation
 //
 // this funcation will place a server into maintenance state by first
@@ -120,47 +370,100 @@ func (rc *RuntimeClient) ShowStat() ([]stat.StatCounters, error) {
 // maintenance state regardless of the number of connections.
 // a context with timeout should be used to avoid waiting forever for the draining to complete
 // the draining can take a long time if there is an active persistent connection
+// the context is also passed to the drain command and the draining checks, while the final
+// change to maintenance state is executed regardless of the context
+// the draining checks are made over a single interactive Session instead of a connection per check,
+// taken from the pool when the client has one
 func (rc *RuntimeClient) ServerMaintenance(ctx context.Context, backend, server string) error {
-	// start by setting the backend server to draining
-	if err := rc.SetServerState(backend, server, ServerStateDrain); err != nil {
+	completed, err := rc.drainServer(ctx, backend, server)
+	if err != nil {
 		return err
 	}
+	// the session of the draining is released before the change, so the change can take it
+	// from a pool limited to a single open session
+	if completed {
+		rc.logger.Info("draining completed - placing server into maintenance", "backend", backend, "server", server)
+	} else {
+		rc.logger.Warn("draining timed out - forcing server into maintenance", "backend", backend, "server", server)
+	}
+	return rc.SetServerState(backend, server, ServerStateMaint)
+}
+
+// set the server to drain and wait for the sessions to end, completed is false when the context ended first
+func (rc *RuntimeClient) drainServer(ctx context.Context, backend, server string) (completed bool, err error) {
+	sess, release, err := rc.session(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	// start by setting the backend server to draining
+	rc.logger.Info("draining server", "backend", backend, "server", server)
+	if err := setServerState(ctx, sess, backend, server, ServerStateDrain); err != nil {
+		return false, err
+	}
+
+	// only the row of the server is requested when checking the draining
+	filter, err := serverFilter(ctx, sess, backend, server)
+	if err != nil {
+		return false, err
+	}
 
 	// time for allowing a pause between checking if draining the backend server is complete
-	timer := time.NewTimer(time.Millisecond * 10)
+	timer := time.NewTimer(rc.pollInterval)
 	defer timer.Stop()
 
 	// check for complete or timeout
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("timeout - force the server to maint state")
-			return rc.SetServerState(backend, server, ServerStateMaint)
+			return false, nil
 		case <-timer.C:
-			completed, err := rc.drainingComplet(backend, server)
+			completed, err := rc.drainingComplet(ctx, sess, filter, backend, server)
 			if err != nil {
-				return err
+				if ctx.Err() != nil {
+					// the context ended during the check - the next select forces maint state
+					continue
+				}
+				return false, err
 			}
 			if completed {
-				return rc.SetServerState(backend, server, ServerStateMaint)
+				return true, nil
 			}
-			timer.Reset(time.Millisecond * 10)
+			timer.Reset(rc.pollInterval)
 		}
 	}
 }
 
-func (rc *RuntimeClient) drainingComplet(backend, server string) (bool, error) {
-	cs, err := rc.ShowStat()
+// get a session for a sequence of commands, from the pool when the client has one so MaxOpen
+// is respected. The session is returned to the pool or closed by calling release
+func (rc *RuntimeClient) session(ctx context.Context) (sess *Session, release func(), err error) {
+	if rc.pool != nil {
+		sess, _, err := rc.pool.get(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sess, func() { rc.pool.put(sess) }, nil
+	}
+	sess, err = rc.OpenSession(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sess, func() { sess.Close() }, nil
+}
+
+func (rc *RuntimeClient) drainingComplet(ctx context.Context, ex executor, filter StatFilter, backend, server string) (bool, error) {
+	cs, err := showStat(ctx, ex, rc.statFormat, filter)
 	if err != nil {
 		return false, err
 	}
 
 	for _, c := range cs {
 		if c.PxName == backend && c.SvName == server {
-			log.Printf("connections for %s/%s %d", backend, server, c.Scur)
+			rc.logger.Debug("draining connections", "backend", backend, "server", server, "connections", c.Scur)
 			return c.Scur == 0, nil
 
 		}
 	}
-	return false, nil
+	return false, fmt.Errorf("%s/%s not found in show stat: %w", backend, server, ErrUnknownServer)
 }
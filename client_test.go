@@ -2,100 +2,317 @@ package haproxy
 
 import (
 	"context"
-	"log"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+	"github.com/industria/haproxy-runtime-api-client/stat"
+	"github.com/industria/haproxy-runtime-api-client/state"
 )
 
-func TestClientDial(t *testing.T) {
-	client, err := NewClient("tcp://localhost:9999")
+// fake HA-Proxy with the backend indexws and the servers iws01 and iws02
+func newFakeHAProxy(t *testing.T) (*haproxytest.Server, *RuntimeClient) {
+	srv := haproxytest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddServer("indexws", "iws01", "172.24.21.40", 8080)
+	srv.AddServer("indexws", "iws02", "172.24.21.41", 8080)
+
+	client, err := NewClient(srv.URI, WithPollInterval(time.Millisecond))
 	if err != nil {
 		t.Fatalf("unable to create client: %v", err)
 	}
+	return srv, client
+}
+
+func TestClientDial(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.Handle("show servers conn", "# bkname/svname bkid/svid fd[0] idle_conn_cur safe_conn_cur used_conn_cur need_conn_est")
 
 	resp, err := client.Execute("show servers conn")
 	if err != nil {
 		t.Fatalf("unable to execute show servers conn : %v", err)
 	}
 
-	log.Printf("RESPONSE: %s", resp)
-
+	if !strings.HasPrefix(string(resp), "# bkname/svname") {
+		t.Fatalf("unexpected response: %s", resp)
+	}
 }
 
 func TestSetServerState(t *testing.T) {
-	client, err := NewClient("tcp://localhost:9999")
+	srv, client := newFakeHAProxy(t)
+
+	for _, s := range []ServerState{ServerStateDrain, ServerStateMaint, ServerStateReady} {
+		err := client.SetServerState("indexws", "iws01", s)
+		if err != nil {
+			t.Fatalf("%s failed : %v", s, err)
+		}
+		if fake, _ := srv.LookupServer("indexws", "iws01"); fake.State != string(s) {
+			t.Fatalf("state not %s: %s", s, fake.State)
+		}
+	}
+}
+
+func TestShowServersState(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws02", func(s *haproxytest.BackendServer) { s.State = "maint" })
+
+	resp, err := client.ShowServersState()
 	if err != nil {
-		t.Fatalf("unable to create client: %v", err)
+		t.Fatalf("failed show state : %v", err)
+	}
+
+	if len(resp) != 2 {
+		t.Fatalf("expected 2 servers got %d", len(resp))
+	}
+	if resp[0].BeName != "indexws" || resp[0].SrvName != "iws01" || resp[0].SrvOpState != state.OperationalStateRunning {
+		t.Fatalf("unexpected state of iws01: %+v", resp[0])
+	}
+	if resp[1].SrvAdminState&state.AdminStateForcedMaintenance == 0 {
+		t.Fatalf("iws02 not in forced maintenance: %+v", resp[1])
 	}
+}
+
+func TestShowStats(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 4 })
 
-	err = client.SetServerState("indexws", "iws01", ServerStateDrain)
+	resp, err := client.ShowStat()
 	if err != nil {
-		t.Fatalf("drain failed : %v", err)
+		t.Fatalf("failed show stat : %v", err)
+	}
+
+	if len(resp) != 3 {
+		t.Fatalf("expected 3 rows got %d", len(resp))
+	}
+	if resp[0].SvName != "iws01" || resp[0].Scur != 4 || resp[0].Status != "UP" {
+		t.Fatalf("unexpected stat of iws01: %+v", resp[0])
+	}
+	if resp[2].SvName != "BACKEND" || resp[2].Scur != 4 {
+		t.Fatalf("unexpected stat of backend: %+v", resp[2])
 	}
+}
 
-	time.Sleep(10 * time.Second)
+func TestShowStatFormats(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws02", func(s *haproxytest.BackendServer) {
+		s.Scur = 3
+		s.Stot = 1234
+		s.State = "drain"
+	})
 
-	err = client.SetServerState("indexws", "iws01", ServerStateMaint)
+	expected, err := client.ShowStat()
 	if err != nil {
-		t.Fatalf("maint failed : %v", err)
+		t.Fatalf("failed show stat : %v", err)
+	}
+
+	for _, format := range []StatFormat{StatFormatTyped, StatFormatJSON} {
+		client.statFormat = format
+		resp, err := client.ShowStat()
+		if err != nil {
+			t.Fatalf("failed show stat %s : %v", format, err)
+		}
+		if !reflect.DeepEqual(resp, expected) {
+			t.Fatalf("show stat %s differs from csv\nexpected: %+v\ngot: %+v", format, expected, resp)
+		}
 	}
 
-	time.Sleep(10 * time.Second)
+	commands := srv.Commands()
+	if commands[1] != "show stat typed" || commands[2] != "show stat json" {
+		t.Fatalf("unexpected commands: %v", commands)
+	}
+}
 
-	err = client.SetServerState("indexws", "iws01", ServerStateReady)
+func TestShowStatJSON(t *testing.T) {
+	_, client := newFakeHAProxy(t)
+
+	fields, err := client.ShowStatJSON()
 	if err != nil {
-		t.Fatalf("ready failed : %v", err)
+		t.Fatalf("failed show stat json : %v", err)
+	}
+	f := fields[0]
+	if f.ObjectType != stat.ObjectTypeServer || f.ProxyID != 1 || f.ObjectID != 1 || f.Name != "pxname" || f.Value != "indexws" {
+		t.Fatalf("unexpected first field: %+v", f)
+	}
+	if f.Origin != stat.OriginKey || f.Nature != stat.NatureName || f.Scope != stat.ScopeService || f.ValueType != stat.ValueTypeStr {
+		t.Fatalf("unexpected tags of first field: %+v", f)
 	}
 }
 
-func TestShowServersState(t *testing.T) {
-	client, err := NewClient("tcp://localhost:9999")
+func TestShowInfo(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws02", func(s *haproxytest.BackendServer) { s.Scur = 5 })
+	srv.SetInfo("Nbthread", "4")
+
+	info, err := client.ShowInfo()
 	if err != nil {
-		t.Fatalf("unable to create client: %v", err)
+		t.Fatalf("failed show info : %v", err)
+	}
+	if info.Name != "HAProxy" || info.Version != "2.6.10" || info.Nbthread != 4 || info.CurrConns != 5 {
+		t.Fatalf("unexpected info: %+v", info)
 	}
+}
 
-	resp, err := client.ShowServersState()
+func TestShowInfoJSON(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws02", func(s *haproxytest.BackendServer) { s.Scur = 5 })
+	srv.SetInfo("Nbthread", "4")
+	srv.SetInfo("QuicConns", "2")
+
+	info, err := client.ShowInfoJSON()
 	if err != nil {
-		t.Fatalf("failed show state : %v", err)
+		t.Fatalf("failed show info json : %v", err)
+	}
+	if info.Name != "HAProxy" || info.Version != "2.6.10" || info.Nbthread != 4 || info.CurrConns != 5 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.Extra["QuicConns"] != "2" {
+		t.Fatalf("expected unknown field in extra got: %v", info.Extra)
+	}
+	if commands := srv.Commands(); commands[0] != "show info json" {
+		t.Fatalf("unexpected command: %s", commands[0])
+	}
+}
+
+func TestServerMaintenance(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 2 })
+
+	// the sessions end a while after draining starts
+	time.AfterFunc(time.Millisecond*50, func() {
+		srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 0 })
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := client.ServerMaintenance(ctx, "indexws", "iws01"); err != nil {
+		t.Fatalf("maintenance failed : %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("draining did not complete before the timeout")
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.State != "maint" {
+		t.Fatalf("state not maint: %s", fake.State)
+	}
+	// the draining checks only request the row of the server
+	for _, command := range srv.Commands() {
+		if strings.HasPrefix(command, "show stat") && command != "show stat 1 4 1" {
+			t.Fatalf("unexpected draining check: %s", command)
+		}
 	}
-	//log.Printf("%+v", resp)
 
-	for _, state := range resp {
-		log.Printf("%s/%s %d %d", state.BeName, state.SrvName, state.SrvAdminState, state.SrvOpState)
+	err := client.SetServerState("indexws", "iws01", ServerStateReady)
+	if err != nil {
+		t.Fatalf("ready failed : %v", err)
 	}
+}
 
+func TestServerMaintenanceTimeout(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 2 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	if err := client.ServerMaintenance(ctx, "indexws", "iws01"); err != nil {
+		t.Fatalf("maintenance failed : %v", err)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.State != "maint" {
+		t.Fatalf("state not forced to maint: %s", fake.State)
+	}
 }
 
-func TestShowStats(t *testing.T) {
-	client, err := NewClient("tcp://localhost:9999")
+func TestServerMaintenancePool(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	if err := client.EnablePool(PoolConfig{MaxOpen: 1}); err != nil {
+		t.Fatalf("unable to enable pool: %v", err)
+	}
+	defer client.Close()
+
+	// the only session of the pool is in use so the draining must wait for it
+	sess, _, err := client.pool.get(context.Background())
 	if err != nil {
-		t.Fatalf("unable to create client: %v", err)
+		t.Fatalf("unable to get a session: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if err := client.ServerMaintenance(ctx, "indexws", "iws01"); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded got: %v", err)
+	}
+	for _, command := range srv.Commands() {
+		if command != "prompt" {
+			t.Fatalf("command sent beyond max open: %s", command)
+		}
 	}
+	client.pool.put(sess)
 
-	resp, err := client.ShowStat()
+	if err := client.ServerMaintenance(context.Background(), "indexws", "iws01"); err != nil {
+		t.Fatalf("maintenance failed : %v", err)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.State != "maint" {
+		t.Fatalf("state not maint: %s", fake.State)
+	}
+}
+
+func TestServerMaintenanceUnknownServer(t *testing.T) {
+	_, client := newFakeHAProxy(t)
+
+	err := client.ServerMaintenance(context.Background(), "indexws", "nope")
+	if !errors.Is(err, ErrUnknownServer) {
+		t.Fatalf("expected unknown server got: %v", err)
+	}
+}
+
+// listener accepting connections without ever responding to commands
+func silentListener(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
-		t.Fatalf("failed show stat : %v", err)
+		t.Fatalf("unable to listen: %v", err)
 	}
-	log.Printf("%+v", resp)
+	t.Cleanup(func() { l.Close() })
 
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	return "tcp://" + l.Addr().String()
 }
 
-func TestServerMaintenance(t *testing.T) {
-	client, err := NewClient("tcp://localhost:9999")
+func TestExecuteContextDeadline(t *testing.T) {
+	client, err := NewClient(silentListener(t))
 	if err != nil {
 		t.Fatalf("unable to create client: %v", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*1000)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
 	defer cancel()
 
-	client.ServerMaintenance(ctx, "indexws", "iws01")
-
-	time.Sleep(10 * time.Second) // hang on for a bit before setting it to ready
+	_, err = client.ExecuteContext(ctx, "show info")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded got: %v", err)
+	}
+}
 
-	err = client.SetServerState("indexws", "iws01", ServerStateReady)
+func TestExecuteContextCancel(t *testing.T) {
+	client, err := NewClient(silentListener(t))
 	if err != nil {
-		t.Fatalf("maint failed : %v", err)
+		t.Fatalf("unable to create client: %v", err)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*50, cancel)
+
+	_, err = client.ExecuteContext(ctx, "show info")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled got: %v", err)
+	}
 }
@@ -0,0 +1,14 @@
+package haproxy
+
+import (
+	"context"
+	"net"
+)
+
+// Dialer connects to the stats socket for a RuntimeClient. A *net.Dialer is used by default
+// and custom implementations can reach the socket through SSH tunnels, inside network
+// namespaces or containers, or serve in-memory pipes in tests.
+// The network is "unix" or "tcp" and the address is taken from the URI given to NewClient.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
@@ -0,0 +1,48 @@
+package haproxy
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+// dialer serving every connection from an in-memory pipe
+type pipeDialer struct {
+	dialed []string
+}
+
+func (d *pipeDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dialed = append(d.dialed, network+" "+address)
+	client, server := net.Pipe()
+	go servePrompt(server, 0)
+	return client, nil
+}
+
+func TestWithDialer(t *testing.T) {
+	dialer := &pipeDialer{}
+	client, err := NewClient("unix:///run/haproxy/admin.sock", WithDialer(dialer))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	resp, err := client.Execute("show info")
+	if err != nil {
+		t.Fatalf("unable to execute show info: %v", err)
+	}
+	if string(resp) != "echo show info\n\n" {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+
+	sess, err := client.OpenSession(context.Background())
+	if err != nil {
+		t.Fatalf("unable to open session: %v", err)
+	}
+	defer sess.Close()
+	if _, err := sess.Execute("show info"); err != nil {
+		t.Fatalf("unable to execute show info on session: %v", err)
+	}
+
+	if len(dialer.dialed) != 2 || dialer.dialed[0] != "unix /run/haproxy/admin.sock" {
+		t.Fatalf("unexpected dials: %q", dialer.dialed)
+	}
+}
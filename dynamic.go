@@ -0,0 +1,198 @@
+package haproxy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/industria/haproxy-runtime-api-client/state"
+)
+
+// Verify is the verification of the certificate of a server using ssl
+type Verify string
+
+const (
+	VerifyNone     Verify = "none"
+	VerifyRequired Verify = "required"
+)
+
+// ServerOptions are the keywords of a server added with add server. Zero values are
+// left out so the defaults of HA-Proxy and the default-server of the backend apply,
+// which means a weight of 0 can not be given here but can be set with SetServerWeight
+type ServerOptions struct {
+	Check   bool          // configure health checks, which AddServer enables with enable health after adding the server
+	Weight  int           // weight between 1 and 256
+	MaxConn int           // maximum number of concurrent connections
+	SSL     bool          // use ssl for the connections to the server
+	Verify  Verify        // verification of the certificate of the server when using ssl
+	Inter   time.Duration // interval between health checks, sent in milliseconds
+	Rise    int           // consecutive successful health checks for the server to be considered up
+	Fall    int           // consecutive failed health checks for the server to be considered down
+	Backup  bool          // only use the server when all other servers are down
+	Cookie  string        // cookie value of the server for cookie persistence
+	Track   string        // track the state of another server given as [<backend>/]<server>
+}
+
+// validate the options returning the first option which is not valid
+func (o *ServerOptions) validate() error {
+	switch {
+	case o.Weight < 0 || o.Weight > 256:
+		return fmt.Errorf("server weight must be between 0 and 256: %d", o.Weight)
+	case o.MaxConn < 0:
+		return fmt.Errorf("server maxconn must not be negative: %d", o.MaxConn)
+	case o.Verify != "" && o.Verify != VerifyNone && o.Verify != VerifyRequired:
+		return fmt.Errorf("unknown server verify: %s", o.Verify)
+	case o.Inter < 0 || (o.Inter > 0 && o.Inter < time.Millisecond):
+		return fmt.Errorf("server inter must be at least a millisecond: %s", o.Inter)
+	case o.Rise < 0:
+		return fmt.Errorf("server rise must not be negative: %d", o.Rise)
+	case o.Fall < 0:
+		return fmt.Errorf("server fall must not be negative: %d", o.Fall)
+	case strings.ContainsAny(o.Cookie, " \t\n"):
+		return fmt.Errorf("server cookie must not contain white space: %q", o.Cookie)
+	case o.Track != "" && (strings.Count(o.Track, "/") > 1 || strings.ContainsAny(o.Track, " \t\n")):
+		return fmt.Errorf("server track must be [<backend>/]<server>: %q", o.Track)
+	}
+	return nil
+}
+
+// the options as keywords of add server
+func (o *ServerOptions) args() []string {
+	var args []string
+	if o.Check {
+		args = append(args, "check")
+	}
+	if o.Weight > 0 {
+		args = append(args, "weight", strconv.Itoa(o.Weight))
+	}
+	if o.MaxConn > 0 {
+		args = append(args, "maxconn", strconv.Itoa(o.MaxConn))
+	}
+	if o.SSL {
+		args = append(args, "ssl")
+	}
+	if o.Verify != "" {
+		args = append(args, "verify", string(o.Verify))
+	}
+	if o.Inter > 0 {
+		args = append(args, "inter", strconv.FormatInt(o.Inter.Milliseconds(), 10)+"ms")
+	}
+	if o.Rise > 0 {
+		args = append(args, "rise", strconv.Itoa(o.Rise))
+	}
+	if o.Fall > 0 {
+		args = append(args, "fall", strconv.Itoa(o.Fall))
+	}
+	if o.Backup {
+		args = append(args, "backup")
+	}
+	if o.Cookie != "" {
+		args = append(args, "cookie", o.Cookie)
+	}
+	if o.Track != "" {
+		args = append(args, "track", o.Track)
+	}
+	return args
+}
+
+// add a server to a backend using add server <backend>/<server> <addr> [options], addr is
+// the address of the server as in the configuration, e.g. 172.24.21.42:8080. The backend
+// must use a dynamic load balancing algorithm. HA-Proxy adds the server in maintenance
+// so it is set to ready with SetServerState when it should receive traffic. HA-Proxy also
+// adds the server with its health checks disabled, so with the Check option they are enabled
+// with EnableCheck after the server is added
+func (rc *RuntimeClient) AddServer(backend, server, addr string, opts ServerOptions) error {
+	return rc.AddServerContext(context.Background(), backend, server, addr, opts)
+}
+
+// add a server to a backend bound to the context
+func (rc *RuntimeClient) AddServerContext(ctx context.Context, backend, server, addr string, opts ServerOptions) error {
+	return addServer(ctx, rc, backend, server, addr, opts)
+}
+
+func addServer(ctx context.Context, ex executor, backend, server, addr string, opts ServerOptions) error {
+	if server == "" || strings.ContainsAny(server, "/ \t\n") {
+		return fmt.Errorf("invalid server name: %q", server)
+	}
+	if addr == "" || strings.ContainsAny(addr, " \t\n") {
+		return fmt.Errorf("invalid server address: %q", addr)
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	command := strings.Join(append([]string{"add", "server", backend + "/" + server, addr}, opts.args()...), " ")
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	if err := expectResponse(command, resp, "New server registered."); err != nil {
+		return err
+	}
+	if opts.Check {
+		return setCheckEnabled(ctx, ex, backend, server, CheckHealth, true)
+	}
+	return nil
+}
+
+// delete a server from a backend using del server <backend>/<server>. HA-Proxy only deletes
+// servers in maintenance without sessions, so the server is first placed into maintenance with
+// ServerMaintenance draining the sessions until the context ends. A server already in maintenance,
+// like a server just added with AddServer, is not drained as draining takes it out of maintenance.
+// The server is not deleted when sessions remain, which is reported as ErrServerNotRemovable
+func (rc *RuntimeClient) DelServer(ctx context.Context, backend, server string) error {
+	s, err := serverState(ctx, rc, backend, server)
+	if err != nil {
+		return err
+	}
+	if s.SrvAdminState&state.AdminStateForcedMaintenance == 0 {
+		if err := rc.ServerMaintenance(ctx, backend, server); err != nil {
+			return err
+		}
+	}
+	// the context bounds the draining, the server is forced into maintenance when it ends
+	// so the deletion is done like that and only bound by the command timeout
+	return delServer(context.Background(), rc, rc.statFormat, backend, server)
+}
+
+// delete a server after checking it is in maintenance without sessions or queued requests
+func delServer(ctx context.Context, ex executor, format StatFormat, backend, server string) error {
+	command := fmt.Sprintf("del server %s/%s", backend, server)
+
+	filter, err := serverFilter(ctx, ex, backend, server)
+	if err != nil {
+		return err
+	}
+	cs, err := showStat(ctx, ex, format, filter)
+	if err != nil {
+		return err
+	}
+	var found bool
+	for _, c := range cs {
+		if c.PxName != backend || c.SvName != server {
+			continue
+		}
+		found = true
+		if !c.Status.IsInMaintenance() {
+			return &CommandError{Command: command, Response: "server is " + c.Status.String(), Err: ErrServerNotRemovable}
+		}
+		if c.Scur > 0 || c.Qcur > 0 {
+			return &CommandError{
+				Command:  command,
+				Response: fmt.Sprintf("server has %d sessions and %d queued requests", c.Scur, c.Qcur),
+				Err:      ErrServerNotRemovable,
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s/%s not found in show stat: %w", backend, server, ErrUnknownServer)
+	}
+
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	return expectResponse(command, resp, "Server deleted.")
+}
@@ -0,0 +1,208 @@
+package haproxy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+)
+
+func TestServerOptionsArgs(t *testing.T) {
+	opts := ServerOptions{
+		Check:   true,
+		Weight:  10,
+		MaxConn: 100,
+		SSL:     true,
+		Verify:  VerifyNone,
+		Inter:   2 * time.Second,
+		Rise:    2,
+		Fall:    3,
+		Backup:  true,
+		Cookie:  "iws03",
+		Track:   "indexws/iws01",
+	}
+	expected := []string{"check", "weight", "10", "maxconn", "100", "ssl", "verify", "none", "inter", "2000ms",
+		"rise", "2", "fall", "3", "backup", "cookie", "iws03", "track", "indexws/iws01"}
+	if args := opts.args(); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	if args := (&ServerOptions{}).args(); len(args) != 0 {
+		t.Fatalf("unexpected args for zero options: %v", args)
+	}
+}
+
+func TestServerOptionsValidate(t *testing.T) {
+	invalid := []ServerOptions{
+		{Weight: 257},
+		{MaxConn: -1},
+		{Verify: "optional"},
+		{Inter: time.Microsecond},
+		{Rise: -1},
+		{Fall: -1},
+		{Cookie: "a b"},
+		{Track: "a/b/c"},
+	}
+	for _, opts := range invalid {
+		if err := opts.validate(); err == nil {
+			t.Fatalf("expected error for %+v", opts)
+		}
+	}
+}
+
+func TestAddServer(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	err := client.AddServer("indexws", "iws03", "172.24.21.42:8080", ServerOptions{Check: true, Weight: 10})
+	if err != nil {
+		t.Fatalf("add server failed: %v", err)
+	}
+	if commands := srv.Commands(); commands[0] != "add server indexws/iws03 172.24.21.42:8080 check weight 10" {
+		t.Fatalf("unexpected command: %s", commands[0])
+	}
+	fake, ok := srv.LookupServer("indexws", "iws03")
+	if !ok {
+		t.Fatalf("server not added")
+	}
+	if fake.Addr != "172.24.21.42" || fake.Port != 8080 || fake.Weight != 10 || fake.State != "maint" {
+		t.Fatalf("unexpected server: %+v", fake)
+	}
+	// HA-Proxy adds the server with the health checks disabled
+	if !fake.Health.Configured || !fake.Health.Enabled {
+		t.Fatalf("health check not enabled: %+v", fake.Health)
+	}
+	if commands := srv.Commands(); commands[1] != "enable health indexws/iws03" {
+		t.Fatalf("unexpected command: %s", commands[1])
+	}
+
+	if err := client.AddServer("indexws", "iws04", "172.24.21.43:8080", ServerOptions{}); err != nil {
+		t.Fatalf("add server failed: %v", err)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws04"); fake.Health.Configured || fake.Health.Enabled {
+		t.Fatalf("health check enabled without the check option: %+v", fake.Health)
+	}
+	for _, command := range srv.Commands() {
+		if command == "enable health indexws/iws04" {
+			t.Fatalf("health check enabled without the check option")
+		}
+	}
+
+	err = client.AddServer("indexws", "iws03", "172.24.21.42:8080", ServerOptions{})
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument for existing server got: %v", err)
+	}
+	err = client.AddServer("nope", "iws03", "172.24.21.42:8080", ServerOptions{})
+	if !errors.Is(err, ErrUnknownBackend) {
+		t.Fatalf("expected unknown backend got: %v", err)
+	}
+	for _, name := range []string{"", "a b", "a/b"} {
+		if err := client.AddServer("indexws", name, "172.24.21.42:8080", ServerOptions{}); err == nil {
+			t.Fatalf("expected error for server name %q", name)
+		}
+	}
+}
+
+func TestDelServer(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 2 })
+
+	// the sessions end a while after draining starts
+	time.AfterFunc(time.Millisecond*50, func() {
+		srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 0 })
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := client.DelServer(ctx, "indexws", "iws01"); err != nil {
+		t.Fatalf("del server failed: %v", err)
+	}
+	if _, ok := srv.LookupServer("indexws", "iws01"); ok {
+		t.Fatalf("server not deleted")
+	}
+	if _, ok := srv.LookupServer("indexws", "iws02"); !ok {
+		t.Fatalf("other server deleted")
+	}
+}
+
+// a server added in maintenance is deleted without draining it out of maintenance
+func TestDelServerAdded(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	if err := client.AddServer("indexws", "iws03", "172.24.21.42:8080", ServerOptions{Check: true}); err != nil {
+		t.Fatalf("add server failed: %v", err)
+	}
+	if err := client.DelServer(context.Background(), "indexws", "iws03"); err != nil {
+		t.Fatalf("del server failed: %v", err)
+	}
+	if _, ok := srv.LookupServer("indexws", "iws03"); ok {
+		t.Fatalf("server not deleted")
+	}
+	for _, command := range srv.Commands() {
+		if strings.HasPrefix(command, "set server indexws/iws03 state") {
+			t.Fatalf("state of server in maintenance changed: %s", command)
+		}
+	}
+}
+
+func TestDelServerSessions(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 2 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	err := client.DelServer(ctx, "indexws", "iws01")
+	if !errors.Is(err, ErrServerNotRemovable) {
+		t.Fatalf("expected server not removable got: %v", err)
+	}
+	fake, ok := srv.LookupServer("indexws", "iws01")
+	if !ok {
+		t.Fatalf("server with sessions deleted")
+	}
+	if fake.State != "maint" {
+		t.Fatalf("state not forced to maint: %s", fake.State)
+	}
+	for _, command := range srv.Commands() {
+		if command == "del server indexws/iws01" {
+			t.Fatalf("del server sent for a server with sessions")
+		}
+	}
+}
+
+func TestDelServerPreconditions(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	// not in maintenance
+	err := delServer(context.Background(), client, StatFormatCSV, "indexws", "iws01")
+	if !errors.Is(err, ErrServerNotRemovable) {
+		t.Fatalf("expected server not removable got: %v", err)
+	}
+
+	// queued requests
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) {
+		s.State = "maint"
+		s.Stats["qcur"] = "1"
+	})
+	err = delServer(context.Background(), client, StatFormatCSV, "indexws", "iws01")
+	if !errors.Is(err, ErrServerNotRemovable) {
+		t.Fatalf("expected server not removable got: %v", err)
+	}
+
+	err = delServer(context.Background(), client, StatFormatCSV, "indexws", "nope")
+	if !errors.Is(err, ErrUnknownServer) {
+		t.Fatalf("expected unknown server got: %v", err)
+	}
+}
+
+func TestDelServerRejected(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.State = "maint" })
+	srv.Handle("del server indexws/iws01", "This server cannot be removed at runtime due to other configuration elements pointing to it.")
+
+	err := delServer(context.Background(), client, StatFormatCSV, "indexws", "iws01")
+	if !errors.Is(err, ErrServerNotRemovable) {
+		t.Fatalf("expected server not removable got: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package haproxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errors recognized from the response text of the Runtime API
+// the errors are wrapped in a CommandError and can be tested with errors.Is
+var (
+	ErrUnknownCommand     = errors.New("unknown command")
+	ErrPermissionDenied   = errors.New("permission denied")    // the access level of the stats socket is too low for the command
+	ErrUnknownBackend     = errors.New("unknown backend")      // also used for unknown proxies
+	ErrUnknownServer      = errors.New("unknown server")       // the server does not exist in the backend
+	ErrInvalidArgument    = errors.New("invalid argument")     // the command was rejected because of its arguments
+	ErrUnexpectedResponse = errors.New("unexpected response")  // the response was not recognized as a result of the command
+	ErrNotApplied         = errors.New("not applied")          // the command succeeded but the change is not visible in the state of HA-Proxy
+	ErrServerNotRemovable = errors.New("server not removable") // the server is not in maintenance, has sessions or is used by other configuration
+)
+
+// CommandError is returned when the Runtime API responds to a command with an error
+type CommandError struct {
+	Command  string // the command sent
+	Response string // the response from the Runtime API with surrounding white space removed
+	Err      error  // one of the sentinel errors of the package
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %s failed with: %s", e.Err, e.Command, e.Response)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// response text prefixes written by HA-Proxy for failing commands. The texts differs a bit
+// between HA-Proxy versions so only the stable beginning of the messages are matched
+var responseErrors = []struct {
+	prefix string
+	err    error
+}{
+	{"Unknown command", ErrUnknownCommand},
+	{"Permission denied", ErrPermissionDenied},
+	{"No such backend", ErrUnknownBackend},
+	{"Can't find backend", ErrUnknownBackend},
+	{"No such proxy", ErrUnknownBackend},
+	{"No such server", ErrUnknownServer},
+	{"Can't find server", ErrUnknownServer},
+	{"Require ", ErrInvalidArgument},
+	{"Invalid ", ErrInvalidArgument},
+	{"Integer value expected", ErrInvalidArgument},
+	{"'set server <srv>", ErrInvalidArgument},
+	{"Absolute weight", ErrInvalidArgument},
+	{"Relative weight", ErrInvalidArgument},
+	{"Backend is using a static LB algorithm", ErrInvalidArgument},
+	{"set server <b>/<s>", ErrInvalidArgument},
+	{"Could not understand IP address format", ErrInvalidArgument},
+	{"Already exists a server with the same name", ErrInvalidArgument},
+	{"Backend must use a dynamic load balancing", ErrInvalidArgument},
+	{"Only servers in maintenance mode can be deleted", ErrServerNotRemovable},
+	{"Server still has connections attached to it", ErrServerNotRemovable},
+	{"This server cannot be removed at runtime", ErrServerNotRemovable},
+	{"Health checks are not configured", ErrInvalidArgument},
+	{"Agent was not configured", ErrInvalidArgument},
+	{"agent checks are not enabled", ErrInvalidArgument},
+	{"cannot change health on a tracking server", ErrInvalidArgument},
+	{"provided port is not valid", ErrInvalidArgument},
+	{"can't unset 'port'", ErrInvalidArgument},
+	{"server ssl setting not supported", ErrInvalidArgument},
+	{"Can't replace a certificate", ErrInvalidArgument},
+	{"Can't update", ErrInvalidArgument},
+	{"Can't commit", ErrInvalidArgument},
+	{"No ongoing transaction", ErrInvalidArgument},
+	{"'set ssl cert'", ErrInvalidArgument},
+	{"'commit ssl cert'", ErrInvalidArgument},
+	{"'abort ssl cert'", ErrInvalidArgument},
+}
+
+// recognize an error response from the first line of the response
+// nil is returned when the response does not start with a known error text
+func responseError(command string, resp []byte) error {
+	line := resp
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	first := strings.TrimSpace(string(line))
+
+	for _, re := range responseErrors {
+		if strings.HasPrefix(first, re.prefix) {
+			return &CommandError{
+				Command:  command,
+				Response: strings.TrimSpace(string(resp)),
+				Err:      re.err,
+			}
+		}
+	}
+	return nil
+}
+
+// check the response of a command answering with an empty line on success
+// any other response is an error, recognized or ErrUnexpectedResponse
+func emptyResponse(command string, resp []byte) error {
+	if len(bytes.TrimSpace(resp)) == 0 {
+		return nil
+	}
+	if err := responseError(command, resp); err != nil {
+		return err
+	}
+	return &CommandError{
+		Command:  command,
+		Response: strings.TrimSpace(string(resp)),
+		Err:      ErrUnexpectedResponse,
+	}
+}
+
+// check the response of a command answering with one of the messages on success
+// any other response is an error, recognized or ErrUnexpectedResponse
+func expectResponse(command string, resp []byte, messages ...string) error {
+	text := strings.TrimSpace(string(resp))
+	for _, message := range messages {
+		if text == message {
+			return nil
+		}
+	}
+	if err := responseError(command, resp); err != nil {
+		return err
+	}
+	return &CommandError{
+		Command:  command,
+		Response: strings.TrimSpace(string(resp)),
+		Err:      ErrUnexpectedResponse,
+	}
+}
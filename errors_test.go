@@ -0,0 +1,98 @@
+package haproxy
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// listener answering every connection with the same response
+func fixedListener(t *testing.T, response string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 1024)
+				conn.Read(buf)
+				conn.Write([]byte(response))
+			}()
+		}
+	}()
+	return "tcp://" + l.Addr().String()
+}
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		response string
+		err      error
+	}{
+		{"Unknown command: 'shw', but maybe one of the following ones is a better match:\n  show stat\n\n", ErrUnknownCommand},
+		{"Unknown command. Please enter one of the following commands only :\n  help\n\n", ErrUnknownCommand},
+		{"Permission denied\n\n", ErrPermissionDenied},
+		{"No such backend.\n\n", ErrUnknownBackend},
+		{"Can't find backend.\n\n", ErrUnknownBackend},
+		{"No such server.\n\n", ErrUnknownServer},
+		{"Require 'backend/server'.\n\n", ErrInvalidArgument},
+		{"'set server <srv> state' expects 'ready', 'drain' and 'maint'.\n\n", ErrInvalidArgument},
+		{"Backend is using a static LB algorithm and only accepts weights '0%' and '100%'.\n\n", ErrInvalidArgument},
+		{"Absolute weight can only be between 0 and 256 inclusive.\n\n", ErrInvalidArgument},
+		{"Only servers in maintenance mode can be deleted.\n\n", ErrServerNotRemovable},
+		{"Server still has connections attached to it, cannot remove it.\n\n", ErrServerNotRemovable},
+		{"Health checks are not configured on this server, cannot enable.\n\n", ErrInvalidArgument},
+		{"agent checks are not enabled on this server.\n\n", ErrInvalidArgument},
+		{"# pxname,svname\nindexws,iws01\n\n", nil},
+		{"\n", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		err := responseError("cmd", []byte(test.response))
+		if test.err == nil {
+			if err != nil {
+				t.Fatalf("expected no error for %q got: %v", test.response, err)
+			}
+			continue
+		}
+		if !errors.Is(err, test.err) {
+			t.Fatalf("expected %v for %q got: %v", test.err, test.response, err)
+		}
+		var ce *CommandError
+		if !errors.As(err, &ce) || ce.Command != "cmd" {
+			t.Fatalf("expected CommandError for %q got: %v", test.response, err)
+		}
+	}
+}
+
+func TestEmptyResponse(t *testing.T) {
+	if err := emptyResponse("cmd", []byte("\n")); err != nil {
+		t.Fatalf("expected line feed to succeed got: %v", err)
+	}
+	if err := emptyResponse("cmd", []byte{}); err != nil {
+		t.Fatalf("expected empty response to succeed got: %v", err)
+	}
+	if err := emptyResponse("cmd", []byte("something else\n")); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Fatalf("expected unexpected response got: %v", err)
+	}
+}
+
+func TestSetServerStateUnknownServer(t *testing.T) {
+	client, err := NewClient(fixedListener(t, "No such server.\n\n"))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	err = client.SetServerState("indexws", "nope", ServerStateDrain)
+	if !errors.Is(err, ErrUnknownServer) {
+		t.Fatalf("expected unknown server got: %v", err)
+	}
+}
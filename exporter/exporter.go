@@ -0,0 +1,187 @@
+// package exporting the stats of HA-Proxy as Prometheus metrics in the text exposition format
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	haproxy "github.com/industria/haproxy-runtime-api-client"
+	"github.com/industria/haproxy-runtime-api-client/info"
+	"github.com/industria/haproxy-runtime-api-client/stat"
+	"github.com/industria/haproxy-runtime-api-client/state"
+)
+
+// content type of the text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Source of the stats exported, implemented by *haproxy.RuntimeClient
+type Source interface {
+	ShowStatContext(ctx context.Context, filter ...haproxy.StatFilter) ([]stat.StatCounters, error)
+	ShowInfoContext(ctx context.Context) (*info.Info, error)
+	ShowServersStateContext(ctx context.Context) ([]state.ServerState, error)
+}
+
+var _ Source = (*haproxy.RuntimeClient)(nil)
+
+// Exporter scrapes show info, show stat and show servers state from a Runtime API
+// and writes the values as metrics in the Prometheus text exposition format
+type Exporter struct {
+	source Source
+}
+
+// export the stats of the source
+func New(source Source) *Exporter {
+	return &Exporter{source: source}
+}
+
+// scrape the stats and write the metrics to w. The metrics of the commands succeeding are
+// written when a command fails and haproxy_up reports if all the commands succeeded.
+// The error of the first command failing is returned after writing the metrics
+func (e *Exporter) Write(ctx context.Context, w io.Writer) error {
+	start := time.Now()
+	var scrapeErr error
+	failed := func(err error) bool {
+		if err != nil && scrapeErr == nil {
+			scrapeErr = err
+		}
+		return err != nil
+	}
+
+	bw := bufio.NewWriter(w)
+	if i, err := e.source.ShowInfoContext(ctx); !failed(err) {
+		writeInfo(bw, i)
+	}
+	if cs, err := e.source.ShowStatContext(ctx); !failed(err) {
+		writeStat(bw, cs)
+	}
+	if ss, err := e.source.ShowServersStateContext(ctx); !failed(err) {
+		writeState(bw, ss)
+	}
+
+	up := boolValue(scrapeErr == nil)
+	writeFamily(bw, "haproxy_up", gauge, "1 when all the stats were scraped from the Runtime API", []sample{{value: up}})
+	writeFamily(bw, "haproxy_scrape_duration_seconds", gauge, "time in seconds scraping the stats",
+		[]sample{{value: time.Since(start).Seconds()}})
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return scrapeErr
+}
+
+// serve the metrics of a scrape bound to the context of the request
+// a failing scrape is reported by haproxy_up with the metrics scraped
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	// the failure is reported in the metrics written
+	_ = e.Write(r.Context(), &buf)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(buf.Bytes())
+}
+
+// a value of a metric with the label names and values in pairs
+type sample struct {
+	labels []string
+	value  float64
+}
+
+func writeInfo(w *bufio.Writer, i *info.Info) {
+	writeFamily(w, "haproxy_process_info", gauge, "version of the process as labels",
+		[]sample{{labels: []string{"version", i.Version, "release_date", i.ReleaseDate, "node", i.Node}, value: 1}})
+	for _, m := range infoMetrics {
+		writeFamily(w, metricName("haproxy_process_"+m.name, m.kind), m.kind, m.help, []sample{{value: m.value(i)}})
+	}
+}
+
+func writeStat(w *bufio.Writer, cs []stat.StatCounters) {
+	metrics := append(append([]statMetric(nil), statMetrics...), statusMetrics...)
+	for _, m := range metrics {
+		var samples []sample
+		for i := range cs {
+			c := &cs[i]
+			if !hasValue(m.types, c.Type) {
+				continue
+			}
+			samples = append(samples, sample{
+				labels: []string{"proxy", c.PxName, "server", c.SvName, "type", c.Type.String()},
+				value:  m.value(c),
+			})
+		}
+		writeFamily(w, metricName("haproxy_stat_"+m.column, m.kind), m.kind, m.help, samples)
+	}
+}
+
+func writeState(w *bufio.Writer, ss []state.ServerState) {
+	for _, m := range stateMetrics {
+		samples := make([]sample, 0, len(ss))
+		for i := range ss {
+			s := &ss[i]
+			samples = append(samples, sample{
+				labels: []string{"proxy", s.BeName, "server", s.SrvName},
+				value:  m.value(s),
+			})
+		}
+		writeFamily(w, "haproxy_server_"+m.name, gauge, m.help, samples)
+	}
+}
+
+// reports if the object type has a value according to the letters of the documentation
+func hasValue(types string, t stat.ObjectType) bool {
+	var letter byte
+	switch t {
+	case stat.ObjectTypeListener:
+		letter = 'L'
+	case stat.ObjectTypeFrontend:
+		letter = 'F'
+	case stat.ObjectTypeBackend:
+		letter = 'B'
+	case stat.ObjectTypeServer:
+		letter = 'S'
+	}
+	return strings.IndexByte(types, letter) >= 0
+}
+
+// counters are named with the suffix _total
+func metricName(name string, kind metricType) string {
+	if kind == counter {
+		return name + "_total"
+	}
+	return name
+}
+
+// write the help, type and samples of a metric, nothing is written without samples
+func writeFamily(w *bufio.Writer, name string, kind metricType, help string, samples []sample) {
+	if len(samples) == 0 {
+		return
+	}
+	w.WriteString("# HELP " + name + " " + helpEscaper.Replace(help) + "\n")
+	w.WriteString("# TYPE " + name + " " + string(kind) + "\n")
+	for _, s := range samples {
+		w.WriteString(name)
+		if len(s.labels) > 0 {
+			w.WriteByte('{')
+			for i := 0; i+1 < len(s.labels); i += 2 {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				w.WriteString(s.labels[i] + `="` + labelEscaper.Replace(s.labels[i+1]) + `"`)
+			}
+			w.WriteByte('}')
+		}
+		w.WriteByte(' ')
+		w.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+		w.WriteByte('\n')
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
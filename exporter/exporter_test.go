@@ -0,0 +1,154 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode"
+
+	haproxy "github.com/industria/haproxy-runtime-api-client"
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+	"github.com/industria/haproxy-runtime-api-client/state"
+)
+
+func newExporter(t *testing.T) (*haproxytest.Server, *Exporter) {
+	srv := haproxytest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddServer("indexws", "iws01", "172.24.21.40", 8080)
+	srv.AddServer("indexws", "iws02", "172.24.21.41", 8080)
+
+	client, err := haproxy.NewClient(srv.URI)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	return srv, New(client)
+}
+
+func TestServeHTTP(t *testing.T) {
+	srv, e := newExporter(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) {
+		s.Scur = 3
+		s.Stot = 42
+	})
+	srv.UpdateServer("indexws", "iws02", func(s *haproxytest.BackendServer) { s.State = "maint" })
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != contentType {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		"haproxy_up 1\n",
+		"# TYPE haproxy_stat_scur gauge\n",
+		`haproxy_stat_scur{proxy="indexws",server="iws01",type="server"} 3` + "\n",
+		`haproxy_stat_scur{proxy="indexws",server="BACKEND",type="backend"} 3` + "\n",
+		"# TYPE haproxy_stat_stot_total counter\n",
+		`haproxy_stat_stot_total{proxy="indexws",server="iws01",type="server"} 42` + "\n",
+		`haproxy_stat_up{proxy="indexws",server="iws01",type="server"} 1` + "\n",
+		`haproxy_stat_maintenance{proxy="indexws",server="iws02",type="server"} 1` + "\n",
+		`haproxy_process_info{version="2.6.10",release_date="2023/03/10",node=""} 1` + "\n",
+		"haproxy_process_current_connections 3\n",
+		`haproxy_server_admin_state{proxy="indexws",server="iws02"} 1` + "\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Fatalf("expected %q in metrics:\n%s", line, body)
+		}
+	}
+
+	// server only columns are not reported for the backend
+	if strings.Contains(body, `haproxy_stat_check_rise{proxy="indexws",server="BACKEND"`) {
+		t.Fatalf("unexpected server column for backend")
+	}
+}
+
+// the help texts of all metrics are complete sentences without cut off parentheses
+func TestMetricHelp(t *testing.T) {
+	helps := map[string]string{}
+	for _, m := range append(append([]statMetric(nil), statMetrics...), statusMetrics...) {
+		helps["haproxy_stat_"+m.column] = m.help
+	}
+	for _, m := range infoMetrics {
+		helps["haproxy_process_"+m.name] = m.help
+	}
+	for _, m := range stateMetrics {
+		helps["haproxy_server_"+m.name] = m.help
+	}
+	for name, help := range helps {
+		if help == "" || help != strings.TrimSpace(help) {
+			t.Fatalf("%s: empty or padded help %q", name, help)
+		}
+		if strings.Count(help, "(") != strings.Count(help, ")") || strings.Count(help, `"`)%2 != 0 {
+			t.Fatalf("%s: unbalanced help %q", name, help)
+		}
+		// help texts start in lowercase unless starting with an acronym like HTTP
+		if len(help) > 1 && unicode.IsUpper(rune(help[0])) && unicode.IsLower(rune(help[1])) {
+			t.Fatalf("%s: help not starting in lowercase %q", name, help)
+		}
+	}
+}
+
+// every HELP line is followed by the TYPE line of the same metric
+func TestHelpLines(t *testing.T) {
+	_, e := newExporter(t)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	lines := strings.Split(rec.Body.String(), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		fields := strings.SplitN(strings.TrimPrefix(line, "# HELP "), " ", 2)
+		if len(fields) != 2 || fields[1] == "" {
+			t.Fatalf("HELP line without help: %q", line)
+		}
+		if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "# TYPE "+fields[0]+" ") {
+			t.Fatalf("HELP line not followed by TYPE line: %q", line)
+		}
+	}
+}
+
+// source failing show servers state
+type failingSource struct {
+	Source
+}
+
+func (s failingSource) ShowServersStateContext(ctx context.Context) ([]state.ServerState, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestWriteScrapeError(t *testing.T) {
+	_, e := newExporter(t)
+	e = New(failingSource{Source: e.source})
+
+	var buf bytes.Buffer
+	if err := e.Write(context.Background(), &buf); err == nil || err.Error() != "connection refused" {
+		t.Fatalf("expected the scrape error got: %v", err)
+	}
+	body := buf.String()
+	if !strings.Contains(body, "haproxy_up 0\n") || !strings.Contains(body, "haproxy_stat_scur{") {
+		t.Fatalf("expected the stats and haproxy_up 0 in metrics:\n%s", body)
+	}
+	if strings.Contains(body, "haproxy_server_") {
+		t.Fatalf("unexpected server state metrics:\n%s", body)
+	}
+}
+
+func TestWriteFamilyEscaping(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	writeFamily(w, "haproxy_test", gauge, "a \\ help\ntext", []sample{{labels: []string{"proxy", `a"b\c`}, value: 0.5}})
+	w.Flush()
+
+	expected := "# HELP haproxy_test a \\\\ help\\ntext\n# TYPE haproxy_test gauge\nhaproxy_test{proxy=\"a\\\"b\\\\c\"} 0.5\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,207 @@
+package exporter
+
+import (
+	"github.com/industria/haproxy-runtime-api-client/info"
+	"github.com/industria/haproxy-runtime-api-client/stat"
+	"github.com/industria/haproxy-runtime-api-client/state"
+)
+
+// type of a metric in the text exposition format
+type metricType string
+
+const (
+	gauge   metricType = "gauge"
+	counter metricType = "counter"
+)
+
+// metric of a show stat column reported as haproxy_stat_<column> for gauges and
+// haproxy_stat_<column>_total for counters with the proxy, server and type labels
+type statMetric struct {
+	column string
+	kind   metricType
+	types  string // the objects having a value as in the documentation: L (listeners), F (frontends), B (backends), S (servers)
+	help   string
+	value  func(c *stat.StatCounters) float64
+}
+
+// the numeric columns of show stat, ids of proxies and servers are not reported as metrics
+var statMetrics = []statMetric{
+	{"qcur", gauge, "..BS", "current queued requests", func(c *stat.StatCounters) float64 { return float64(c.Qcur) }},
+	{"qmax", gauge, "..BS", "max value of qcur", func(c *stat.StatCounters) float64 { return float64(c.Qmax) }},
+	{"scur", gauge, "LFBS", "current sessions", func(c *stat.StatCounters) float64 { return float64(c.Scur) }},
+	{"smax", gauge, "LFBS", "max sessions", func(c *stat.StatCounters) float64 { return float64(c.Smax) }},
+	{"slim", gauge, "LFBS", "configured session limit", func(c *stat.StatCounters) float64 { return float64(c.Slim) }},
+	{"stot", counter, "LFBS", "cumulative number of sessions", func(c *stat.StatCounters) float64 { return float64(c.Stot) }},
+	{"bin", counter, "LFBS", "bytes in", func(c *stat.StatCounters) float64 { return float64(c.Bin) }},
+	{"bout", counter, "LFBS", "bytes out", func(c *stat.StatCounters) float64 { return float64(c.Bout) }},
+	{"dreq", counter, "LFB.", "requests denied because of security concerns", func(c *stat.StatCounters) float64 { return float64(c.Dreg) }},
+	{"dresp", counter, "LFBS", "responses denied because of security concerns", func(c *stat.StatCounters) float64 { return float64(c.Dresp) }},
+	{"ereq", counter, "LF..", "request errors", func(c *stat.StatCounters) float64 { return float64(c.Ereg) }},
+	{"econ", counter, "..BS", "number of requests that encountered an error trying to connect to a backend server", func(c *stat.StatCounters) float64 { return float64(c.Econ) }},
+	{"eresp", counter, "..BS", "response errors", func(c *stat.StatCounters) float64 { return float64(c.Eresp) }},
+	{"wretr", counter, "..BS", "number of times a connection to a server was retried", func(c *stat.StatCounters) float64 { return float64(c.Wretr) }},
+	{"wredis", counter, "..BS", "number of times a request was redispatched to another server", func(c *stat.StatCounters) float64 { return float64(c.Wredis) }},
+	{"weight", gauge, "..BS", "total effective weight (backend), effective weight (server)", func(c *stat.StatCounters) float64 { return float64(c.Weight) }},
+	{"act", gauge, "..BS", "number of active servers (backend), server is active (server)", func(c *stat.StatCounters) float64 { return float64(c.Act) }},
+	{"bck", gauge, "..BS", "number of backup servers (backend), server is backup (server)", func(c *stat.StatCounters) float64 { return float64(c.Bck) }},
+	{"chkfail", counter, "...S", "number of failed checks", func(c *stat.StatCounters) float64 { return float64(c.ChkFail) }},
+	{"chkdown", counter, "..BS", "number of UP->DOWN transitions", func(c *stat.StatCounters) float64 { return float64(c.ChkDown) }},
+	{"lastchg", gauge, "..BS", "number of seconds since the last UP<->DOWN transition", func(c *stat.StatCounters) float64 { return float64(c.LastChg) }},
+	{"downtime", counter, "..BS", "total downtime (in seconds)", func(c *stat.StatCounters) float64 { return float64(c.Downtime) }},
+	{"qlimit", gauge, "...S", "configured maxqueue for the server, or nothing in the value is 0 (default, meaning no limit)", func(c *stat.StatCounters) float64 { return float64(c.Qlimit) }},
+	{"throttle", gauge, "...S", "current throttle percentage for the server, when slowstart is active, or no value if not in slowstart", func(c *stat.StatCounters) float64 { return float64(c.Throttle) }},
+	{"lbtot", counter, "..BS", "total number of times a server was selected, either for new sessions, or when re-dispatching", func(c *stat.StatCounters) float64 { return float64(c.Lbtot) }},
+	{"rate", gauge, ".FBS", "number of sessions per second over last elapsed second", func(c *stat.StatCounters) float64 { return float64(c.Rate) }},
+	{"rate_lim", gauge, ".F..", "configured limit on new sessions per second", func(c *stat.StatCounters) float64 { return float64(c.RateLim) }},
+	{"rate_max", gauge, ".FBS", "max number of new sessions per second", func(c *stat.StatCounters) float64 { return float64(c.RateMax) }},
+	{"check_code", gauge, "...S", "layer5-7 code, if available HTTP/SMTP/LDAP status code reported by the latest server health check", func(c *stat.StatCounters) float64 { return float64(c.CheckCode) }},
+	{"check_duration", gauge, "...S", "time in ms took to finish last health check", func(c *stat.StatCounters) float64 { return float64(c.CheckDuration) }},
+	{"hrsp_1xx", counter, ".FBS", "http responses with 1xx code", func(c *stat.StatCounters) float64 { return float64(c.Hrsp1xx) }},
+	{"hrsp_2xx", counter, ".FBS", "http responses with 2xx code", func(c *stat.StatCounters) float64 { return float64(c.Hrsp2xx) }},
+	{"hrsp_3xx", counter, ".FBS", "http responses with 3xx code", func(c *stat.StatCounters) float64 { return float64(c.Hrsp3xx) }},
+	{"hrsp_4xx", counter, ".FBS", "http responses with 4xx code", func(c *stat.StatCounters) float64 { return float64(c.Hrsp4xx) }},
+	{"hrsp_5xx", counter, ".FBS", "http responses with 5xx code", func(c *stat.StatCounters) float64 { return float64(c.Hrsp5xx) }},
+	{"hrsp_other", counter, ".FBS", "http responses with other codes (protocol error)", func(c *stat.StatCounters) float64 { return float64(c.HrspOther) }},
+	{"hanafail", counter, "...S", "failed health checks details", func(c *stat.StatCounters) float64 { return float64(c.HanaFail) }},
+	{"req_rate", gauge, ".F..", "HTTP requests per second over last elapsed second", func(c *stat.StatCounters) float64 { return float64(c.ReqRate) }},
+	{"req_rate_max", gauge, ".F..", "max number of HTTP requests per second observed", func(c *stat.StatCounters) float64 { return float64(c.ReqRateMax) }},
+	{"req_tot", counter, ".FB.", "total number of HTTP requests received", func(c *stat.StatCounters) float64 { return float64(c.ReqTot) }},
+	{"cli_abrt", counter, "..BS", "number of data transfers aborted by the client", func(c *stat.StatCounters) float64 { return float64(c.CliAbrt) }},
+	{"srv_abrt", counter, "..BS", "number of data transfers aborted by the server (inc. in eresp)", func(c *stat.StatCounters) float64 { return float64(c.SrvAbrt) }},
+	{"comp_in", counter, ".FB.", "number of HTTP response bytes fed to the compressor", func(c *stat.StatCounters) float64 { return float64(c.CompIn) }},
+	{"comp_out", counter, ".FB.", "number of HTTP response bytes emitted by the compressor", func(c *stat.StatCounters) float64 { return float64(c.CompOut) }},
+	{"comp_byp", counter, ".FB.", "number of bytes that bypassed the HTTP compressor (CPU/BW limit)", func(c *stat.StatCounters) float64 { return float64(c.CompByp) }},
+	{"comp_rsp", counter, ".FB.", "number of HTTP responses that were compressed", func(c *stat.StatCounters) float64 { return float64(c.CompRsp) }},
+	{"lastsess", gauge, "..BS", "number of seconds since last session assigned to server/backend", func(c *stat.StatCounters) float64 { return float64(c.LastSess) }},
+	{"qtime", gauge, "..BS", "the average queue time in ms over the 1024 last requests", func(c *stat.StatCounters) float64 { return float64(c.Qtime) }},
+	{"ctime", gauge, "..BS", "the average connect time in ms over the 1024 last requests", func(c *stat.StatCounters) float64 { return float64(c.Ctime) }},
+	{"rtime", gauge, "..BS", "the average response time in ms over the 1024 last requests (0 for TCP)", func(c *stat.StatCounters) float64 { return float64(c.Rtime) }},
+	{"ttime", gauge, "..BS", "the average total session time in ms over the 1024 last requests", func(c *stat.StatCounters) float64 { return float64(c.Ttime) }},
+	{"agent_code", gauge, "...S", "numeric code reported by agent if any (unused for now)", func(c *stat.StatCounters) float64 { return float64(c.AgentCode) }},
+	{"agent_duration", gauge, "...S", "time in ms taken to finish last check", func(c *stat.StatCounters) float64 { return float64(c.AgentDuration) }},
+	{"check_rise", gauge, "...S", "server's \"rise\" parameter used by checks, number of successful health checks before declaring a server UP (server 'rise' setting)", func(c *stat.StatCounters) float64 { return float64(c.CheckRise) }},
+	{"check_fall", gauge, "...S", "server's \"fall\" parameter used by checks, number of failed health checks before declaring a server DOWN (server 'fall' setting)", func(c *stat.StatCounters) float64 { return float64(c.CheckFall) }},
+	{"check_health", gauge, "...S", "server's health check value between 0 and rise+fall-1, current server health check level (0..fall-1=DOWN, fall..rise-1=UP)", func(c *stat.StatCounters) float64 { return float64(c.CheckHealth) }},
+	{"agent_rise", gauge, "...S", "agent's \"rise\" parameter, normally 1", func(c *stat.StatCounters) float64 { return float64(c.AgentRise) }},
+	{"agent_fall", gauge, "...S", "agent's \"fall\" parameter, normally 1", func(c *stat.StatCounters) float64 { return float64(c.AgentFall) }},
+	{"agent_health", gauge, "...S", "agent's health parameter, between 0 and rise+fall-1", func(c *stat.StatCounters) float64 { return float64(c.AgentHealth) }},
+	{"conn_rate", gauge, ".F..", "number of connections over the last elapsed second", func(c *stat.StatCounters) float64 { return float64(c.ConnRate) }},
+	{"conn_rate_max", gauge, ".F..", "highest known conn_rate", func(c *stat.StatCounters) float64 { return float64(c.ConnRateMax) }},
+	{"conn_tot", counter, ".F..", "cumulative number of connections", func(c *stat.StatCounters) float64 { return float64(c.ConnTot) }},
+	{"intercepted", counter, ".FB.", "total number of HTTP requests intercepted on the frontend (redirects/stats/services) since the worker process started", func(c *stat.StatCounters) float64 { return float64(c.Intercepted) }},
+	{"dcon", counter, "LF..", "requests denied by \"tcp-request connection\" rules", func(c *stat.StatCounters) float64 { return float64(c.Dcon) }},
+	{"dses", counter, "LF..", "requests denied by \"tcp-request session\" rules", func(c *stat.StatCounters) float64 { return float64(c.Dses) }},
+	{"wrew", counter, "LFBS", "cumulative number of failed header rewriting warnings", func(c *stat.StatCounters) float64 { return float64(c.Wrew) }},
+	{"connect", counter, "..BS", "cumulative number of connection establishment attempts", func(c *stat.StatCounters) float64 { return float64(c.Connect) }},
+	{"reuse", counter, "..BS", "cumulative number of connection reuses", func(c *stat.StatCounters) float64 { return float64(c.Reuse) }},
+	{"cache_lookups", counter, ".FB.", "cumulative number of cache lookups", func(c *stat.StatCounters) float64 { return float64(c.CacheLookups) }},
+	{"cache_hits", counter, ".FB.", "cumulative number of cache hits", func(c *stat.StatCounters) float64 { return float64(c.CacheHits) }},
+	{"srv_icur", gauge, "...S", "current number of idle connections available for reuse", func(c *stat.StatCounters) float64 { return float64(c.SrvIcur) }},
+	{"src_ilim", gauge, "...S", "limit on the number of available idle connections", func(c *stat.StatCounters) float64 { return float64(c.SrcIlim) }},
+	{"qtime_max", gauge, "..BS", "the maximum observed queue time in ms", func(c *stat.StatCounters) float64 { return float64(c.QtimeMax) }},
+	{"ctime_max", gauge, "..BS", "the maximum observed connect time in ms", func(c *stat.StatCounters) float64 { return float64(c.CtimeMax) }},
+	{"rtime_max", gauge, "..BS", "the maximum observed response time in ms (0 for TCP)", func(c *stat.StatCounters) float64 { return float64(c.RtimeMax) }},
+	{"ttime_max", gauge, "..BS", "the maximum observed total session time in ms", func(c *stat.StatCounters) float64 { return float64(c.TtimeMax) }},
+	{"eint", counter, "LFBS", "cumulative number of internal errors", func(c *stat.StatCounters) float64 { return float64(c.Eint) }},
+	{"idle_conn_cur", gauge, "...S", "current number of unsafe idle connections", func(c *stat.StatCounters) float64 { return float64(c.IdleConnCur) }},
+	{"safe_conn_cur", gauge, "...S", "current number of safe idle connections", func(c *stat.StatCounters) float64 { return float64(c.SafeConnCur) }},
+	{"used_conn_cur", gauge, "...S", "current number of connections in use", func(c *stat.StatCounters) float64 { return float64(c.UsedConnCur) }},
+	{"need_conn_est", gauge, "...S", "estimated needed number of connections", func(c *stat.StatCounters) float64 { return float64(c.NeedConnEst) }},
+	{"uweight", gauge, "..BS", "total user weight (backend), server user weight (server)", func(c *stat.StatCounters) float64 { return float64(c.Uweight) }},
+	{"agg_server_status", gauge, "..B.", "backend's aggregated gauge of servers' status", func(c *stat.StatCounters) float64 { return float64(c.AggServerStatus) }},
+	{"agg_server_check_status", gauge, "..B.", "deprecated - backend's aggregated gauge of servers' state check status", func(c *stat.StatCounters) float64 { return float64(c.AggServerCheckStatus) }},
+	{"agg_check_status", gauge, "..B.", "backend's aggregated gauge of servers' state check status", func(c *stat.StatCounters) float64 { return float64(c.AggCheckStatus) }},
+	{"ssl_sess", counter, ".FBS", "total number of ssl sessions established", func(c *stat.StatCounters) float64 { return float64(c.SSL.Sess) }},
+	{"ssl_reused_sess", counter, ".FBS", "total number of ssl sessions reused", func(c *stat.StatCounters) float64 { return float64(c.SSL.ReusedSess) }},
+	{"ssl_failed_handshake", counter, ".FBS", "total number of failed handshake", func(c *stat.StatCounters) float64 { return float64(c.SSL.FailedHandshake) }},
+	{"h2_headers_rcvd", counter, ".FB.", "total number of received HEADERS frames", func(c *stat.StatCounters) float64 { return float64(c.H2.HeadersRcvd) }},
+	{"h2_data_rcvd", counter, ".FB.", "total number of received DATA frames", func(c *stat.StatCounters) float64 { return float64(c.H2.DataRcvd) }},
+	{"h2_settings_rcvd", counter, ".FB.", "total number of received SETTINGS frames", func(c *stat.StatCounters) float64 { return float64(c.H2.SettingsRcvd) }},
+	{"h2_rst_stream_rcvd", counter, ".FB.", "total number of received RST_STREAM frames", func(c *stat.StatCounters) float64 { return float64(c.H2.RstStreamRcvd) }},
+	{"h2_goaway_rcvd", counter, ".FB.", "total number of received GOAWAY frames", func(c *stat.StatCounters) float64 { return float64(c.H2.GoawayRcvd) }},
+	{"h2_detected_conn_protocol_errors", counter, ".FB.", "total number of connection protocol errors", func(c *stat.StatCounters) float64 { return float64(c.H2.DetectedConnProtocolErrors) }},
+	{"h2_detected_strm_protocol_errors", counter, ".FB.", "total number of stream protocol errors", func(c *stat.StatCounters) float64 { return float64(c.H2.DetectedStrmProtocolErrors) }},
+	{"h2_rst_stream_resp", counter, ".FB.", "total number of RST_STREAM sent on detected error", func(c *stat.StatCounters) float64 { return float64(c.H2.RstStreamResp) }},
+	{"h2_goaway_resp", counter, ".FB.", "total number of GOAWAY sent on detected error", func(c *stat.StatCounters) float64 { return float64(c.H2.GoawayResp) }},
+	{"h2_open_connections", gauge, ".FB.", "count of currently open connections", func(c *stat.StatCounters) float64 { return float64(c.H2.OpenConnections) }},
+	{"h2_backend_open_streams", gauge, ".FB.", "count of currently open streams", func(c *stat.StatCounters) float64 { return float64(c.H2.BackendOpenStreams) }},
+	{"h2_total_connections", counter, ".FB.", "total number of connections", func(c *stat.StatCounters) float64 { return float64(c.H2.TotalConnections) }},
+	{"h2_backend_total_streams", counter, ".FB.", "total number of streams", func(c *stat.StatCounters) float64 { return float64(c.H2.BackendTotalStreams) }},
+	{"h1_open_connections", gauge, ".FB.", "count of currently open connections", func(c *stat.StatCounters) float64 { return float64(c.H1.OpenConnections) }},
+	{"h1_open_streams", gauge, ".FB.", "count of currently open streams", func(c *stat.StatCounters) float64 { return float64(c.H1.OpenStreams) }},
+	{"h1_total_connections", counter, ".FB.", "total number of connections", func(c *stat.StatCounters) float64 { return float64(c.H1.TotalConnections) }},
+	{"h1_total_streams", counter, ".FB.", "total number of streams", func(c *stat.StatCounters) float64 { return float64(c.H1.TotalStreams) }},
+	{"h1_bytes_in", counter, ".FB.", "total number of bytes received", func(c *stat.StatCounters) float64 { return float64(c.H1.BytesIn) }},
+	{"h1_bytes_out", counter, ".FB.", "total number of bytes send", func(c *stat.StatCounters) float64 { return float64(c.H1.BytesOut) }},
+	{"h1_spliced_bytes_in", counter, ".FB.", "total number of bytes received using kernel splicing", func(c *stat.StatCounters) float64 { return float64(c.H1.SplicedBytesIn) }},
+	{"h1_spliced_bytes_out", counter, ".FB.", "total number of bytes sent using kernel splicing", func(c *stat.StatCounters) float64 { return float64(c.H1.SplicedBytesOut) }},
+}
+
+// metrics derived from the status of the objects
+var statusMetrics = []statMetric{
+	{"up", gauge, "LFBS", "1 when the object is up, including servers draining and frontends open", func(c *stat.StatCounters) float64 { return boolValue(c.Status.IsUp()) }},
+	{"maintenance", gauge, "...S", "1 when the server is in maintenance", func(c *stat.StatCounters) float64 { return boolValue(c.Status.IsInMaintenance()) }},
+	{"draining", gauge, "...S", "1 when the server is draining", func(c *stat.StatCounters) float64 { return boolValue(c.Status.IsDraining()) }},
+}
+
+// metric of show info reported as haproxy_process_<name>
+type infoMetric struct {
+	name  string
+	kind  metricType
+	help  string
+	value func(i *info.Info) float64
+}
+
+var infoMetrics = []infoMetric{
+	{"nbthread", gauge, "number of started threads", func(i *info.Info) float64 { return float64(i.Nbthread) }},
+	{"uptime_seconds", gauge, "time in seconds since the process was started", func(i *info.Info) float64 { return float64(i.UptimeSec) }},
+	{"start_time_seconds", gauge, "start time of the process in seconds since the epoch", func(i *info.Info) float64 { return float64(i.StartTimeSec) }},
+	{"max_memory_bytes", gauge, "memory limit of the process in bytes, 0 for no limit", func(i *info.Info) float64 { return float64(i.MemmaxBytes) }},
+	{"pool_allocated_bytes", gauge, "memory allocated in pools in bytes", func(i *info.Info) float64 { return float64(i.PoolAllocBytes) }},
+	{"pool_used_bytes", gauge, "memory used from the pools in bytes", func(i *info.Info) float64 { return float64(i.PoolUsedBytes) }},
+	{"pool_failures", counter, "number of failed pool allocations", func(i *info.Info) float64 { return float64(i.PoolFailed) }},
+	{"max_fds", gauge, "maximum number of open files of the process", func(i *info.Info) float64 { return float64(i.UlimitN) }},
+	{"max_connections", gauge, "maximum number of concurrent connections", func(i *info.Info) float64 { return float64(i.Maxconn) }},
+	{"current_connections", gauge, "current number of connections", func(i *info.Info) float64 { return float64(i.CurrConns) }},
+	{"connections", counter, "total number of connections", func(i *info.Info) float64 { return float64(i.CumConns) }},
+	{"requests", counter, "total number of requests", func(i *info.Info) float64 { return float64(i.CumReq) }},
+	{"current_ssl_connections", gauge, "current number of SSL connections", func(i *info.Info) float64 { return float64(i.CurrSslConns) }},
+	{"ssl_connections", counter, "total number of SSL connections", func(i *info.Info) float64 { return float64(i.CumSslConns) }},
+	{"current_connection_rate", gauge, "number of connections over the last second", func(i *info.Info) float64 { return float64(i.ConnRate) }},
+	{"current_session_rate", gauge, "number of sessions over the last second", func(i *info.Info) float64 { return float64(i.SessRate) }},
+	{"current_ssl_rate", gauge, "number of SSL sessions over the last second", func(i *info.Info) float64 { return float64(i.SslRate) }},
+	{"current_tasks", gauge, "number of tasks", func(i *info.Info) float64 { return float64(i.Tasks) }},
+	{"current_run_queue", gauge, "number of tasks in the run queue", func(i *info.Info) float64 { return float64(i.RunQueue) }},
+	{"idle_percent", gauge, "percentage of the time the process was idle", func(i *info.Info) float64 { return float64(i.IdlePct) }},
+	{"stopping", gauge, "1 when the process is stopping after a reload or a stop", func(i *info.Info) float64 { return boolValue(i.Stopping) }},
+	{"jobs", gauge, "number of active jobs", func(i *info.Info) float64 { return float64(i.Jobs) }},
+	{"listeners", gauge, "number of listeners", func(i *info.Info) float64 { return float64(i.Listeners) }},
+	{"dropped_logs", counter, "total number of dropped log messages", func(i *info.Info) float64 { return float64(i.DroppedLogs) }},
+	{"failed_resolutions", counter, "total number of failed DNS resolutions", func(i *info.Info) float64 { return float64(i.FailedResolutions) }},
+	{"bytes_out", counter, "total number of bytes sent", func(i *info.Info) float64 { return float64(i.TotalBytesOut) }},
+	{"max_connections_reached", counter, "number of times the maximum number of connections was reached", func(i *info.Info) float64 { return float64(i.MaxconnReached) }},
+}
+
+// metric of show servers state reported as haproxy_server_<name> with the proxy and server labels
+type stateMetric struct {
+	name  string
+	help  string
+	value func(s *state.ServerState) float64
+}
+
+var stateMetrics = []stateMetric{
+	{"operational_state", "operational state of the server: 0 stopped, 1 starting, 2 running, 3 stopping", func(s *state.ServerState) float64 { return float64(s.SrvOpState) }},
+	{"admin_state", "administrative state of the server as a mask: 0x01 forced maintenance, 0x08 forced drain and more", func(s *state.ServerState) float64 { return float64(s.SrvAdminState) }},
+	{"user_weight", "weight of the server set by the user", func(s *state.ServerState) float64 { return float64(s.SrvUWeight) }},
+	{"initial_weight", "weight of the server in the configuration", func(s *state.ServerState) float64 { return float64(s.SrvIWeight) }},
+	{"seconds_since_last_change", "time in seconds since the last operational change", func(s *state.ServerState) float64 { return float64(s.SrvTimeSinceLastChange) }},
+	{"check_result", "result of the last health check: 0 unknown, 1 neutral, 2 failed, 3 passed, 4 conditionally passed", func(s *state.ServerState) float64 { return float64(s.SrvCheckResult) }},
+	{"check_health", "rise and fall counter of the health checks", func(s *state.ServerState) float64 { return float64(s.SrvCheckHealth) }},
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
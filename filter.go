@@ -0,0 +1,93 @@
+package haproxy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/industria/haproxy-runtime-api-client/state"
+)
+
+// StatType is a mask of the types of objects reported by show stat
+type StatType int
+
+const (
+	StatTypeFrontends StatType = 1
+	StatTypeBackends  StatType = 2
+	StatTypeServers   StatType = 4
+)
+
+// StatFilter limits the rows reported by show stat to a proxy, types of objects and a server
+// mapped to the arguments of show stat <iid> <type> <sid>. The zero value reports all rows
+type StatFilter struct {
+	ProxyID  int      // unique id of the proxy (iid), 0 for all proxies
+	Types    StatType // mask of the object types, 0 for all types
+	ServerID int      // unique id of the server within the proxy (sid), 0 for all servers
+}
+
+// the arguments of show stat for the filter where -1 selects everything
+func (f StatFilter) args() string {
+	id := func(v int) string {
+		if v <= 0 {
+			return "-1"
+		}
+		return strconv.Itoa(v)
+	}
+	return strings.Join([]string{id(f.ProxyID), id(int(f.Types)), id(f.ServerID)}, " ")
+}
+
+// the show stat command for the optional filter in the format
+func statCommand(format StatFormat, filter []StatFilter) string {
+	command := "show stat"
+	if len(filter) > 0 {
+		command += " " + filter[0].args()
+	}
+	if format == StatFormatTyped || format == StatFormatJSON {
+		command += " " + string(format)
+	}
+	return command
+}
+
+// look up the unique id of a proxy (iid) by name using the frontend and backend rows of show stat
+// an unknown proxy is reported as ErrUnknownBackend
+func (rc *RuntimeClient) ProxyID(ctx context.Context, proxy string) (int, error) {
+	cs, err := showStat(ctx, rc, rc.statFormat, StatFilter{Types: StatTypeFrontends | StatTypeBackends})
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range cs {
+		if c.PxName == proxy {
+			return int(c.Iid), nil
+		}
+	}
+	return 0, fmt.Errorf("%s not found in show stat: %w", proxy, ErrUnknownBackend)
+}
+
+// look up the filter reporting only the row of a server by the backend and server names
+// the ids are resolved with show servers state for the backend
+func (rc *RuntimeClient) ServerFilter(ctx context.Context, backend, server string) (StatFilter, error) {
+	return serverFilter(ctx, rc, backend, server)
+}
+
+func serverFilter(ctx context.Context, ex executor, backend, server string) (StatFilter, error) {
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return StatFilter{}, err
+	}
+	return StatFilter{ProxyID: s.BeId, Types: StatTypeServers, ServerID: s.SrvId}, nil
+}
+
+// get the state of a single server using show servers state <backend>
+func serverState(ctx context.Context, ex executor, backend, server string) (*state.ServerState, error) {
+	states, err := showServersState(ctx, ex, backend)
+	if err != nil {
+		return nil, err
+	}
+	for i := range states {
+		if states[i].BeName == backend && states[i].SrvName == server {
+			return &states[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s/%s not found in show servers state: %w", backend, server, ErrUnknownServer)
+}
@@ -0,0 +1,86 @@
+package haproxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStatCommand(t *testing.T) {
+	tests := []struct {
+		format   StatFormat
+		filter   []StatFilter
+		expected string
+	}{
+		{StatFormatCSV, nil, "show stat"},
+		{StatFormatJSON, nil, "show stat json"},
+		{StatFormatCSV, []StatFilter{{}}, "show stat -1 -1 -1"},
+		{StatFormatCSV, []StatFilter{{ProxyID: 4, Types: StatTypeServers, ServerID: 1}}, "show stat 4 4 1"},
+		{StatFormatTyped, []StatFilter{{Types: StatTypeFrontends | StatTypeBackends}}, "show stat -1 3 -1 typed"},
+	}
+	for _, test := range tests {
+		if command := statCommand(test.format, test.filter); command != test.expected {
+			t.Fatalf("expected %q got %q", test.expected, command)
+		}
+	}
+}
+
+func TestShowStatFilter(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.AddServer("solr", "solr01", "172.24.21.50", 8983)
+
+	resp, err := client.ShowStat(StatFilter{ProxyID: 1, Types: StatTypeServers, ServerID: 2})
+	if err != nil {
+		t.Fatalf("failed show stat : %v", err)
+	}
+	if len(resp) != 1 || resp[0].PxName != "indexws" || resp[0].SvName != "iws02" {
+		t.Fatalf("expected only iws02 got: %+v", resp)
+	}
+
+	resp, err = client.ShowStat(StatFilter{Types: StatTypeBackends})
+	if err != nil {
+		t.Fatalf("failed show stat : %v", err)
+	}
+	if len(resp) != 2 || resp[0].SvName != "BACKEND" || resp[1].PxName != "solr" {
+		t.Fatalf("expected the backends got: %+v", resp)
+	}
+}
+
+func TestProxyID(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.AddServer("solr", "solr01", "172.24.21.50", 8983)
+
+	id, err := client.ProxyID(context.Background(), "solr")
+	if err != nil {
+		t.Fatalf("unable to look up proxy id: %v", err)
+	}
+	if id != 2 {
+		t.Fatalf("expected proxy id 2 got %d", id)
+	}
+
+	if _, err := client.ProxyID(context.Background(), "missing"); !errors.Is(err, ErrUnknownBackend) {
+		t.Fatalf("expected ErrUnknownBackend got: %v", err)
+	}
+}
+
+func TestServerFilter(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	filter, err := client.ServerFilter(context.Background(), "indexws", "iws02")
+	if err != nil {
+		t.Fatalf("unable to look up server filter: %v", err)
+	}
+	if filter != (StatFilter{ProxyID: 1, Types: StatTypeServers, ServerID: 2}) {
+		t.Fatalf("unexpected filter: %+v", filter)
+	}
+	if commands := srv.Commands(); commands[len(commands)-1] != "show servers state indexws" {
+		t.Fatalf("unexpected commands: %v", commands)
+	}
+
+	if _, err := client.ServerFilter(context.Background(), "indexws", "iws09"); !errors.Is(err, ErrUnknownServer) {
+		t.Fatalf("expected ErrUnknownServer got: %v", err)
+	}
+	if _, err := client.ServerFilter(context.Background(), "missing", "iws01"); !errors.Is(err, ErrUnknownBackend) {
+		t.Fatalf("expected ErrUnknownBackend got: %v", err)
+	}
+}
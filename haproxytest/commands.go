@@ -0,0 +1,728 @@
+package haproxytest
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the built in commands of the fake server
+var builtins = []command{
+	{words: []string{"show", "info"}, level: levelUser, fn: (*Server).showInfo},
+	{words: []string{"show", "stat"}, level: levelUser, fn: (*Server).showStat},
+	{words: []string{"show", "servers", "state"}, level: levelUser, fn: (*Server).showServersState},
+	{words: []string{"set", "server"}, level: levelAdmin, fn: (*Server).setServer},
+	{words: []string{"set", "weight"}, level: levelAdmin, fn: (*Server).setWeight},
+	{words: []string{"get", "weight"}, level: levelUser, fn: (*Server).getWeight},
+	{words: []string{"add", "server"}, level: levelAdmin, fn: (*Server).addServer},
+	{words: []string{"del", "server"}, level: levelAdmin, fn: (*Server).delServer},
+	{words: []string{"set", "ssl", "cert"}, level: levelAdmin, fn: (*Server).setSSLCert},
+	{words: []string{"commit", "ssl", "cert"}, level: levelAdmin, fn: (*Server).commitSSLCert},
+	{words: []string{"abort", "ssl", "cert"}, level: levelAdmin, fn: (*Server).abortSSLCert},
+	{words: []string{"enable", "health"}, level: levelAdmin, fn: (*Server).enableCheck},
+	{words: []string{"disable", "health"}, level: levelAdmin, fn: (*Server).enableCheck},
+	{words: []string{"enable", "agent"}, level: levelAdmin, fn: (*Server).enableCheck},
+	{words: []string{"disable", "agent"}, level: levelAdmin, fn: (*Server).enableCheck},
+}
+
+// show stat header as written by HA-Proxy 2.6
+const statHeader = "# pxname,svname,qcur,qmax,scur,smax,slim,stot,bin,bout,dreq,dresp,ereq,econ,eresp,wretr,wredis,status,weight,act,bck,chkfail,chkdown,lastchg,downtime,qlimit,pid,iid,sid,throttle,lbtot,tracked,type,rate,rate_lim,rate_max,check_status,check_code,check_duration,hrsp_1xx,hrsp_2xx,hrsp_3xx,hrsp_4xx,hrsp_5xx,hrsp_other,hanafail,req_rate,req_rate_max,req_tot,cli_abrt,srv_abrt,comp_in,comp_out,comp_byp,comp_rsp,lastsess,last_chk,last_agt,qtime,ctime,rtime,ttime,agent_status,agent_code,agent_duration,check_desc,agent_desc,check_rise,check_fall,check_health,agent_rise,agent_fall,agent_health,addr,cookie,mode,algo,conn_rate,conn_rate_max,conn_tot,intercepted,dcon,dses,wrew,connect,reuse,cache_lookups,cache_hits,srv_icur,src_ilim,qtime_max,ctime_max,rtime_max,ttime_max,eint,idle_conn_cur,safe_conn_cur,used_conn_cur,need_conn_est,uweight,agg_server_status,agg_server_check_status,agg_check_status,-,ssl_sess,ssl_reused_sess,ssl_failed_handshake,h2_headers_rcvd,h2_data_rcvd,h2_settings_rcvd,h2_rst_stream_rcvd,h2_goaway_rcvd,h2_detected_conn_protocol_errors,h2_detected_strm_protocol_errors,h2_rst_stream_resp,h2_goaway_resp,h2_open_connections,h2_backend_open_streams,h2_total_connections,h2_backend_total_streams,h1_open_connections,h1_open_streams,h1_total_connections,h1_total_streams,h1_bytes_in,h1_bytes_out,h1_spliced_bytes_in,h1_spliced_bytes_out,"
+
+// show servers state header as written by HA-Proxy 2.6
+const serversStateHeader = "# be_id be_name srv_id srv_name srv_addr srv_op_state srv_admin_state srv_uweight srv_iweight srv_time_since_last_change srv_check_status srv_check_result srv_check_health srv_check_state srv_agent_state bk_f_forced_id srv_f_forced_id srv_fqdn srv_port srvrecord srv_use_ssl srv_check_port srv_check_addr srv_agent_addr srv_agent_port"
+
+// show stat [{<iid>|<proxy>} <type> <sid>] [typed|json]
+func (s *Server) showStat(args []string) string {
+	columns := strings.Split(strings.TrimSuffix(strings.TrimPrefix(statHeader, "# "), ","), ",")
+
+	filter, msg := s.statFilter(args)
+	if msg != "" {
+		return msg
+	}
+
+	var rows []map[string]string
+	for _, b := range s.backends {
+		if filter.proxyID >= 0 && filter.proxyID != b.ID {
+			continue
+		}
+		var scur uint32
+		var stot uint64
+		for _, srv := range b.Servers {
+			scur += srv.Scur
+			stot += srv.Stot
+			if filter.types&4 != 0 && (filter.serverID < 0 || filter.serverID == srv.ID) {
+				rows = append(rows, serverStats(b, srv))
+			}
+		}
+		if filter.types&2 != 0 {
+			rows = append(rows, backendStats(b, scur, stot))
+		}
+	}
+
+	switch statFormat(args) {
+	case "typed":
+		return typedStat(columns, rows)
+	case "json":
+		return jsonStat(columns, rows)
+	}
+
+	var out strings.Builder
+	out.WriteString(statHeader + "\n")
+	for _, row := range rows {
+		writeStatRow(&out, columns, row)
+	}
+	return out.String()
+}
+
+// the proxy, object type mask and server of show stat where -1 selects everything
+type statFilter struct {
+	proxyID  int
+	types    int
+	serverID int
+}
+
+// parse the optional <iid>|<proxy> <type> <sid> arguments of show stat
+func (s *Server) statFilter(args []string) (statFilter, string) {
+	filter := statFilter{proxyID: -1, types: -1, serverID: -1}
+	if len(args) < 5 || args[2] == "typed" || args[2] == "json" {
+		return filter, ""
+	}
+
+	// numbers are parsed with atoi by HA-Proxy so anything else is read as 0
+	if b := s.backend(args[2]); b != nil {
+		filter.proxyID = b.ID
+	} else if id, _ := strconv.Atoi(args[2]); id != 0 {
+		filter.proxyID = id
+	} else {
+		return filter, "No such proxy.\n"
+	}
+	filter.types, _ = strconv.Atoi(args[3])
+	filter.serverID, _ = strconv.Atoi(args[4])
+	return filter, ""
+}
+
+// the output format requested from show stat, empty for CSV
+func statFormat(args []string) string {
+	for _, arg := range args[2:] {
+		if arg == "typed" || arg == "json" {
+			return arg
+		}
+	}
+	return ""
+}
+
+func writeStatRow(out *strings.Builder, columns []string, values map[string]string) {
+	for _, c := range columns {
+		out.WriteString(values[c])
+		out.WriteByte(',')
+	}
+	out.WriteByte('\n')
+}
+
+// show stat status of a server
+func serverStatus(srv *BackendServer) string {
+	switch srv.State {
+	case "maint":
+		return "MAINT"
+	case "drain":
+		return "DRAIN"
+	}
+	switch srv.Status {
+	case "down":
+		return "DOWN"
+	case "stopping":
+		return "NOLB"
+	default:
+		return "UP"
+	}
+}
+
+func serverStats(b *Backend, srv *BackendServer) map[string]string {
+	values := map[string]string{
+		"pxname":       b.Name,
+		"svname":       srv.Name,
+		"scur":         strconv.FormatUint(uint64(srv.Scur), 10),
+		"stot":         strconv.FormatUint(srv.Stot, 10),
+		"status":       serverStatus(srv),
+		"weight":       strconv.Itoa(srv.Weight),
+		"act":          "1",
+		"bck":          "0",
+		"pid":          "1",
+		"iid":          strconv.Itoa(b.ID),
+		"sid":          strconv.Itoa(srv.ID),
+		"type":         "2",
+		"check_status": "L7OK",
+		"check_code":   "200",
+		"addr":         fmt.Sprintf("%s:%d", srv.Addr, srv.Port),
+		"mode":         b.Mode,
+		"uweight":      strconv.Itoa(srv.Weight),
+	}
+	for k, v := range srv.Stats {
+		values[k] = v
+	}
+	return values
+}
+
+func backendStats(b *Backend, scur uint32, stot uint64) map[string]string {
+	weight := 0
+	act := 0
+	for _, srv := range b.Servers {
+		if srv.State == "ready" {
+			weight += srv.Weight
+			act++
+		}
+	}
+	status := "UP"
+	if act == 0 {
+		status = "DOWN"
+	}
+	return map[string]string{
+		"pxname":  b.Name,
+		"svname":  "BACKEND",
+		"scur":    strconv.FormatUint(uint64(scur), 10),
+		"stot":    strconv.FormatUint(stot, 10),
+		"status":  status,
+		"weight":  strconv.Itoa(weight),
+		"act":     strconv.Itoa(act),
+		"bck":     "0",
+		"pid":     "1",
+		"iid":     strconv.Itoa(b.ID),
+		"sid":     "0",
+		"type":    "1",
+		"mode":    b.Mode,
+		"algo":    "roundrobin",
+		"uweight": strconv.Itoa(weight),
+	}
+}
+
+// show info [json], the connection counters are the sums of the server sessions
+func (s *Server) showInfo(args []string) string {
+	var scur uint32
+	var stot uint64
+	for _, b := range s.backends {
+		for _, srv := range b.Servers {
+			scur += srv.Scur
+			stot += srv.Stot
+		}
+	}
+	values := map[string]string{
+		"Name":         "HAProxy",
+		"Version":      "2.6.10",
+		"Release_date": "2023/03/10",
+		"Nbthread":     "1",
+		"Nbproc":       "1",
+		"Process_num":  "1",
+		"Pid":          "1",
+		"Uptime":       "0d 0h00m00s",
+		"Uptime_sec":   "0",
+		"Maxconn":      "4096",
+		"Hard_maxconn": "4096",
+		"CurrConns":    strconv.FormatUint(uint64(scur), 10),
+		"CumConns":     strconv.FormatUint(stot, 10),
+		"CumReq":       strconv.FormatUint(stot, 10),
+		"Tasks":        "1",
+		"Run_queue":    "0",
+		"Idle_pct":     "100",
+		"Stopping":     "0",
+	}
+	for k, v := range s.info {
+		values[k] = v
+	}
+
+	// the fields in the order of HA-Proxy followed by the other fields set in alphabetical order
+	var names []string
+	for _, name := range infoFields {
+		if _, ok := values[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var others []string
+	for name := range values {
+		if !contains(infoFields, name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	names = append(names, others...)
+
+	if len(args) > 2 && args[2] == "json" {
+		return jsonInfo(names, values)
+	}
+	var out strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&out, "%s: %s\n", name, values[name])
+	}
+	return out.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// show info fields in the order written by HA-Proxy 2.6
+var infoFields = []string{
+	"Name", "Version", "Release_date", "Nbthread", "Nbproc", "Process_num", "Pid", "Uptime", "Uptime_sec",
+	"Memmax_MB", "PoolAlloc_MB", "PoolUsed_MB", "PoolFailed", "Ulimit-n", "Maxsock", "Maxconn", "Hard_maxconn",
+	"CurrConns", "CumConns", "CumReq", "MaxSslConns", "CurrSslConns", "CumSslConns", "Maxpipes", "PipesUsed",
+	"PipesFree", "ConnRate", "ConnRateLimit", "MaxConnRate", "SessRate", "SessRateLimit", "MaxSessRate",
+	"SslRate", "SslRateLimit", "MaxSslRate", "SslFrontendKeyRate", "SslFrontendMaxKeyRate",
+	"SslFrontendSessionReuse_pct", "SslBackendKeyRate", "SslBackendMaxKeyRate", "SslCacheLookups",
+	"SslCacheMisses", "CompressBpsIn", "CompressBpsOut", "CompressBpsRateLim", "ZlibMemUsage", "MaxZlibMemUsage",
+	"Tasks", "Run_queue", "Idle_pct", "node", "description", "Stopping", "Jobs", "Unstoppable Jobs", "Listeners",
+	"ActivePeers", "ConnectedPeers", "DroppedLogs", "BusyPolling", "FailedResolutions", "TotalBytesOut",
+	"TotalSplicdedBytesOut", "BytesOutRate", "DebugCommandsIssued", "CumRecvLogs", "Build info", "Memmax_bytes",
+	"PoolAlloc_bytes", "PoolUsed_bytes", "Start_time_sec", "Tainted", "TotalWarnings", "MaxconnReached",
+	"BootTime_ms",
+}
+
+// show servers state [<backend>]
+func (s *Server) showServersState(args []string) string {
+	backends := s.backends
+	if len(args) > 3 {
+		b := s.backend(args[3])
+		if b == nil {
+			return "Can't find backend.\n"
+		}
+		backends = []*Backend{b}
+	}
+
+	var out strings.Builder
+	out.WriteString("1\n")
+	out.WriteString(serversStateHeader + "\n")
+	for _, b := range backends {
+		for _, srv := range b.Servers {
+			opState := 2
+			switch srv.Status {
+			case "down":
+				opState = 0
+			case "stopping":
+				opState = 3
+			}
+			adminState := 0
+			switch srv.State {
+			case "maint":
+				opState = 0
+				adminState = 0x01
+			case "drain":
+				adminState = 0x08
+			}
+			fmt.Fprintf(&out, "%d %s %d %s %s %d %d %d %d 0 15 3 4 %d %d 0 0 %s %d - %d %d %s %s %d\n",
+				b.ID, b.Name, srv.ID, srv.Name, srv.Addr, opState, adminState, srv.Weight, srv.InitWeight,
+				checkState(srv.Health, false), checkState(srv.Agent, true),
+				orDash(srv.FQDN), srv.Port, boolFlag(srv.SSL), srv.Health.Port, orDash(srv.Health.Addr), orDash(srv.Agent.Addr), srv.Agent.Port)
+		}
+	}
+	return out.String()
+}
+
+// srv_check_state and srv_agent_state mask of a check
+func checkState(c Check, agent bool) int {
+	mask := 0
+	if c.Configured {
+		mask |= 0x02
+	}
+	if c.Enabled {
+		mask |= 0x04
+	}
+	if agent && mask != 0 {
+		mask |= 0x10
+	}
+	return mask
+}
+
+func boolFlag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// set server <backend>/<server> <setting> ...
+func (s *Server) setServer(args []string) string {
+	if len(args) < 4 {
+		return "Require 'backend/server'.\n"
+	}
+	srv, msg := s.lookup(args[2])
+	if srv == nil {
+		return msg
+	}
+
+	switch args[3] {
+	case "state":
+		if len(args) < 5 {
+			return "'set server <srv> state' expects 'ready', 'drain' and 'maint'.\n"
+		}
+		switch args[4] {
+		case "ready", "drain", "maint":
+			srv.State = args[4]
+			return ""
+		default:
+			return "'set server <srv> state' expects 'ready', 'drain' and 'maint'.\n"
+		}
+	case "weight":
+		if len(args) < 5 {
+			return "Require <weight> or <weight%>.\n"
+		}
+		return changeWeight(srv, args[4])
+	case "addr":
+		return changeAddr(srv, args[4:])
+	case "fqdn":
+		if len(args) < 5 {
+			return "set server <b>/<s> fqdn requires a FQDN.\n"
+		}
+		if srv.FQDN == args[4] {
+			return "no need to change the FDQN by 'stats socket command'\n"
+		}
+		from := srv.FQDN
+		if from == "" {
+			from = "(null)"
+		}
+		srv.FQDN = args[4]
+		return fmt.Sprintf("%s changed its FQDN from %s to %s by 'stats socket command'\n", args[2], from, args[4])
+	case "health":
+		switch arg(args, 4) {
+		case "up", "stopping", "down":
+			srv.Status = args[4]
+			return ""
+		default:
+			return "'set server <srv> health' expects 'up', 'stopping', or 'down'.\n"
+		}
+	case "agent":
+		if !srv.Agent.Enabled {
+			return "agent checks are not enabled on this server.\n"
+		}
+		switch arg(args, 4) {
+		case "up", "down":
+			srv.Status = args[4]
+			return ""
+		default:
+			return "'set server <srv> agent' expects 'up' or 'down'.\n"
+		}
+	case "check-port":
+		port, err := strconv.Atoi(arg(args, 4))
+		if err != nil {
+			return "'set server <srv> check-port' expects an integer as argument.\n"
+		}
+		if port < 0 || port > 65535 {
+			return "provided port is not valid.\n"
+		}
+		srv.Health.Port = port
+		srv.Agent.Port = port
+		return "health check port updated.\n"
+	case "check-addr", "agent-addr":
+		c, name := &srv.Health, "health check"
+		if args[3] == "agent-addr" {
+			c, name = &srv.Agent, "agent"
+		}
+		if net.ParseIP(arg(args, 4)) == nil || (len(args) > 5 && (args[5] != "port" || len(args) < 7)) {
+			return "set server <b>/<s> " + args[3] + " requires an address and optionally a port.\n"
+		}
+		port := c.Port
+		if len(args) > 6 {
+			p, err := strconv.Atoi(args[6])
+			if err != nil || p < 1 || p > 65535 {
+				return "provided port is not valid.\n"
+			}
+			port = p
+		}
+		c.Addr, c.Port = args[4], port
+		return name + " addr updated.\n"
+	case "agent-send":
+		if !srv.Agent.Configured {
+			return "agent checks are not enabled on this server.\n"
+		}
+		if len(args) < 5 {
+			return "set server <b>/<s> agent-send requires a string.\n"
+		}
+		// only the first word is used as by HA-Proxy
+		srv.Agent.Send = args[4]
+		return "agent send string updated.\n"
+	case "ssl":
+		switch arg(args, 4) {
+		case "on", "off":
+			srv.SSL = args[4] == "on"
+			return "server ssl setting updated.\n"
+		default:
+			return "'set server <srv> ssl' expects 'on' or 'off'.\n"
+		}
+	default:
+		return "'set server <srv>' only supports 'agent', 'health', 'state', 'weight', 'addr', 'fqdn', 'check-addr', 'check-port', 'agent-addr', 'agent-port', 'agent-send' and 'ssl'.\n"
+	}
+}
+
+// change the address and port of a server reporting the changes as HA-Proxy does
+// for set server <backend>/<server> addr <ip> [port <port>]
+func changeAddr(srv *BackendServer, args []string) string {
+	if len(args) == 0 || (len(args) > 1 && (args[1] != "port" || len(args) < 3)) {
+		return "set server <b>/<s> addr requires an address and optionally a port.\n"
+	}
+	if net.ParseIP(args[0]) == nil {
+		return "Could not understand IP address format.\n"
+	}
+	port := srv.Port
+	if len(args) > 2 {
+		p, err := strconv.Atoi(args[2])
+		if err != nil || p < 1 || p > 65535 {
+			return "Invalid port '" + args[2] + "'.\n"
+		}
+		port = p
+	}
+
+	var msgs []string
+	if args[0] != srv.Addr {
+		msgs = append(msgs, fmt.Sprintf("IP changed from '%s' to '%s'", srv.Addr, args[0]))
+		srv.Addr = args[0]
+	} else {
+		msgs = append(msgs, "no need to change the addr")
+	}
+	if len(args) > 2 {
+		if port != srv.Port {
+			msgs = append(msgs, fmt.Sprintf("port changed from '%d' to '%d'", srv.Port, port))
+			srv.Port = port
+		} else {
+			msgs = append(msgs, "no need to change the port")
+		}
+	}
+	return strings.Join(msgs, ", ") + " by 'stats socket command'\n"
+}
+
+// set weight <backend>/<server> <weight>[%]
+func (s *Server) setWeight(args []string) string {
+	if len(args) < 4 {
+		return "Require 'backend/server' and 'weight' or 'weight%'.\n"
+	}
+	srv, msg := s.lookup(args[2])
+	if srv == nil {
+		return msg
+	}
+	return changeWeight(srv, args[3])
+}
+
+// change the weight of a server as HA-Proxy does with a dynamic load balancing algorithm
+func changeWeight(srv *BackendServer, arg string) string {
+	relative := strings.HasSuffix(arg, "%")
+	w, err := strconv.Atoi(strings.TrimSuffix(arg, "%"))
+	if err != nil {
+		return "Require <weight> or <weight%>.\n"
+	}
+	if relative {
+		if w < 0 {
+			return "Relative weight must be positive.\n"
+		}
+		w = srv.InitWeight * w / 100
+		if w > 256 {
+			w = 256
+		}
+	} else if w < 0 || w > 256 {
+		return "Absolute weight can only be between 0 and 256 inclusive.\n"
+	}
+	srv.Weight = w
+	return ""
+}
+
+// get weight <backend>/<server>
+func (s *Server) getWeight(args []string) string {
+	if len(args) < 3 {
+		return "Require 'backend/server'.\n"
+	}
+	srv, msg := s.lookup(args[2])
+	if srv == nil {
+		return msg
+	}
+	return fmt.Sprintf("%d (initial %d)\n", srv.Weight, srv.InitWeight)
+}
+
+// keywords of add server taking a value
+var serverKeywords = map[string]bool{
+	"weight": true, "maxconn": true, "verify": true, "inter": true, "rise": true,
+	"fall": true, "cookie": true, "track": true, "port": true, "addr": true,
+}
+
+// add server <backend>/<server> <addr>[:<port>] [keywords]
+// the server is added in maintenance as HA-Proxy does
+func (s *Server) addServer(args []string) string {
+	if len(args) < 4 {
+		return "'server' expects <name> and <addr>[:<port>] as arguments.\n"
+	}
+	backend, name, ok := splitServer(args[2])
+	if !ok {
+		return "Require 'backend/server'.\n"
+	}
+	b := s.backend(backend)
+	if b == nil {
+		return "No such backend.\n"
+	}
+	if s.server(backend, name) != nil {
+		return "Already exists a server with the same name in backend.\n"
+	}
+
+	addr, port := args[3], 0
+	if host, p, err := net.SplitHostPort(args[3]); err == nil {
+		addr = host
+		if port, err = strconv.Atoi(p); err != nil {
+			return "Invalid port '" + p + "'.\n"
+		}
+	}
+	srv := &BackendServer{
+		ID:         nextServerID(b),
+		Name:       name,
+		Addr:       addr,
+		Port:       port,
+		State:      "maint",
+		Weight:     1,
+		InitWeight: 1,
+		Stats:      make(map[string]string),
+	}
+	for i := 4; i < len(args); i++ {
+		switch kw := args[i]; {
+		case kw == "check":
+			srv.Health.Configured = true
+		case kw == "ssl":
+			srv.SSL = true
+		case kw == "backup":
+		case serverKeywords[kw]:
+			if i+1 >= len(args) {
+				return "'" + kw + "' expects an argument.\n"
+			}
+			i++
+			if kw == "weight" {
+				w, err := strconv.Atoi(args[i])
+				if err != nil || w < 0 || w > 256 {
+					return "weight of server " + name + " is not within 0 and 256 (" + args[i] + ").\n"
+				}
+				srv.Weight, srv.InitWeight = w, w
+			}
+		default:
+			return "'server " + name + "' unknown keyword '" + kw + "'.\n"
+		}
+	}
+	b.Servers = append(b.Servers, srv)
+	return "New server registered.\n"
+}
+
+// del server <backend>/<server>
+// only servers in maintenance without sessions are deleted as by HA-Proxy
+func (s *Server) delServer(args []string) string {
+	if len(args) < 3 {
+		return "Require 'backend/server'.\n"
+	}
+	srv, msg := s.lookup(args[2])
+	if srv == nil {
+		return msg
+	}
+	if srv.State != "maint" {
+		return "Only servers in maintenance mode can be deleted.\n"
+	}
+	if srv.Scur > 0 {
+		return "Server still has connections attached to it, cannot remove it.\n"
+	}
+
+	backend, _, _ := splitServer(args[2])
+	b := s.backend(backend)
+	for i, bs := range b.Servers {
+		if bs == srv {
+			b.Servers = append(b.Servers[:i], b.Servers[i+1:]...)
+			break
+		}
+	}
+	return "Server deleted.\n"
+}
+
+// enable health|agent <backend>/<server> and disable health|agent <backend>/<server>
+func (s *Server) enableCheck(args []string) string {
+	if len(args) < 3 {
+		return "Require 'backend/server'.\n"
+	}
+	srv, msg := s.lookup(args[2])
+	if srv == nil {
+		return msg
+	}
+
+	c := &srv.Health
+	if args[1] == "agent" {
+		c = &srv.Agent
+	}
+	if args[0] == "disable" {
+		c.Enabled = false
+		return ""
+	}
+	if !c.Configured {
+		if args[1] == "agent" {
+			return "Agent was not configured on this server, cannot enable.\n"
+		}
+		return "Health checks are not configured on this server, cannot enable.\n"
+	}
+	c.Enabled = true
+	return ""
+}
+
+// the argument at i, empty when missing
+func arg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// set ssl cert <file> <payload> with the payload given after << as the last argument
+func (s *Server) setSSLCert(args []string) string {
+	if len(args) < 6 || args[4] != "<<" {
+		return "'set ssl cert' expects a filename and a certificate as a payload\n"
+	}
+	file, payload := args[3], args[5]
+	if _, ok := s.certs[file]; !ok {
+		return "Can't replace a certificate which is not referenced by the configuration!\n"
+	}
+	if !strings.Contains(payload, "-----BEGIN CERTIFICATE-----") {
+		return "Can't update " + file + "!\nunable to load certificate from file '" + file + "'.\n"
+	}
+	if s.certTx == nil {
+		s.certTx = make(map[string]string)
+	}
+	_, updated := s.certTx[file]
+	s.certTx[file] = payload
+	if updated {
+		return "Transaction updated for certificate " + file + "!\n"
+	}
+	return "Transaction created for certificate " + file + "!\n"
+}
+
+// commit ssl cert <file>
+func (s *Server) commitSSLCert(args []string) string {
+	if len(args) < 4 {
+		return "'commit ssl cert' expects a filename\n"
+	}
+	payload, ok := s.certTx[args[3]]
+	if !ok {
+		return "No ongoing transaction! !\n"
+	}
+	delete(s.certTx, args[3])
+	s.certs[args[3]] = payload
+	return "Committing " + args[3] + ".\nSuccess!\n"
+}
+
+// abort ssl cert <file>
+func (s *Server) abortSSLCert(args []string) string {
+	if len(args) < 4 {
+		return "'abort ssl cert' expects a filename\n"
+	}
+	if _, ok := s.certTx[args[3]]; !ok {
+		return "No ongoing transaction!\n"
+	}
+	delete(s.certTx, args[3])
+	return "Transaction aborted for certificate '" + args[3] + "'!\n"
+}
@@ -0,0 +1,483 @@
+// package providing a fake HA-Proxy Runtime API for testing
+package haproxytest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// HandlerFunc answers a command, args are the white space separated words of the command
+// and the result is the output of the command without the trailing empty line
+type HandlerFunc func(args []string) string
+
+// access levels of a connection in the order of increasing permissions
+const (
+	levelUser = iota
+	levelOperator
+	levelAdmin
+)
+
+// a built in command, words is the beginning of the command identifying it
+type command struct {
+	words []string
+	level int
+	fn    func(s *Server, args []string) string
+}
+
+// Server is a fake HA-Proxy Runtime API listening on a tcp or unix socket. The server
+// models backends and servers which are reported by show stat and show servers state
+// and changed by commands like set server. Commands can be scripted with Handle
+// and HandleFunc. A Server is safe for concurrent use.
+type Server struct {
+	URI string // URI for NewClient in the form tcp://address:port or unix://path
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	backends []*Backend
+	info     map[string]string
+	certs    map[string]string // committed certificates by file name
+	certTx   map[string]string // certificates of ongoing transactions by file name
+	handlers []scripted
+	commands []string
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// a scripted command
+type scripted struct {
+	words []string
+	fn    HandlerFunc
+}
+
+// Backend modelled by the fake server
+type Backend struct {
+	ID      int
+	Name    string
+	Mode    string // proxy mode reported by show stat, http by default
+	Servers []*BackendServer
+}
+
+// BackendServer modelled by the fake server. State, Weight and the other fields are
+// changed by the commands received, and Stats can set any show stat column by name
+type BackendServer struct {
+	ID         int
+	Name       string
+	Addr       string
+	Port       int
+	FQDN       string // set with set server fqdn, empty when not set
+	State      string // ready, drain or maint as set with set server state
+	Weight     int    // current weight
+	InitWeight int    // weight from the configuration
+	Scur       uint32 // current sessions
+	Stot       uint64 // total sessions
+	Health     Check  // health check, configured and enabled by default
+	Agent      Check  // agent check, not configured by default
+	Status     string // up, stopping or down as forced with set server health and agent, up when empty
+	SSL        bool   // use ssl for the connections to the server as set with set server ssl
+	Stats      map[string]string
+}
+
+// Check of a server modelled by the fake server
+type Check struct {
+	Configured bool
+	Enabled    bool
+	Addr       string // check-addr or agent-addr, empty when not set
+	Port       int    // check-port or agent-port, 0 when not set
+	Send       string // agent-send
+}
+
+// start a fake server listening on a tcp port on the loopback interface
+// panics if the server can not listen as in net/http/httptest
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("haproxytest: failed to listen: %v", err))
+	}
+	return start(l, "tcp://"+l.Addr().String())
+}
+
+// start a fake server listening on a unix socket at path
+// panics if the server can not listen as in net/http/httptest
+func NewUnixServer(path string) *Server {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		panic(fmt.Sprintf("haproxytest: failed to listen on %s: %v", path, err))
+	}
+	return start(l, "unix://"+path)
+}
+
+func start(l net.Listener, uri string) *Server {
+	s := &Server{
+		URI:      uri,
+		listener: l,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// stop listening and close all open connections
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// add a backend, adding an existing backend does nothing
+func (s *Server) AddBackend(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addBackend(name)
+}
+
+func (s *Server) addBackend(name string) *Backend {
+	if b := s.backend(name); b != nil {
+		return b
+	}
+	b := &Backend{
+		ID:   len(s.backends) + 1,
+		Name: name,
+		Mode: "http",
+	}
+	s.backends = append(s.backends, b)
+	return b
+}
+
+// add a ready server with weight 1 to the backend, the backend is added if it does not exist
+func (s *Server) AddServer(backend, name, addr string, port int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.addBackend(backend)
+	b.Servers = append(b.Servers, &BackendServer{
+		ID:         nextServerID(b),
+		Name:       name,
+		Addr:       addr,
+		Port:       port,
+		State:      "ready",
+		Weight:     1,
+		InitWeight: 1,
+		Health:     Check{Configured: true, Enabled: true},
+		Stats:      make(map[string]string),
+	})
+}
+
+func nextServerID(b *Backend) int {
+	id := 1
+	for _, srv := range b.Servers {
+		if srv.ID >= id {
+			id = srv.ID + 1
+		}
+	}
+	return id
+}
+
+// change a server while holding the lock of the fake server
+// reports false if the server does not exist
+func (s *Server) UpdateServer(backend, name string, fn func(srv *BackendServer)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	srv := s.server(backend, name)
+	if srv == nil {
+		return false
+	}
+	fn(srv)
+	return true
+}
+
+// get a copy of a server, reports false if the server does not exist
+func (s *Server) LookupServer(backend, name string) (BackendServer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	srv := s.server(backend, name)
+	if srv == nil {
+		return BackendServer{}, false
+	}
+	cp := *srv
+	cp.Stats = make(map[string]string, len(srv.Stats))
+	for k, v := range srv.Stats {
+		cp.Stats[k] = v
+	}
+	return cp, true
+}
+
+// set a field reported by show info, e.g. SetInfo("Nbthread", "4")
+func (s *Server) SetInfo(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.info == nil {
+		s.info = make(map[string]string)
+	}
+	s.info[name] = value
+}
+
+// add a certificate file referenced by the configuration, which can be updated with set ssl cert
+func (s *Server) AddCert(file, payload string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.certs == nil {
+		s.certs = make(map[string]string)
+	}
+	s.certs[file] = payload
+}
+
+// get the committed payload of a certificate file
+func (s *Server) Cert(file string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payload, ok := s.certs[file]
+	return payload, ok
+}
+
+// answer commands starting with command using a fixed response
+func (s *Server) Handle(command, response string) {
+	s.HandleFunc(command, func(args []string) string { return response })
+}
+
+// answer commands starting with command using the handler. Scripted commands take
+// precedence over the built in commands and the last registered handler matching is used
+func (s *Server) HandleFunc(command string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, scripted{words: strings.Fields(command), fn: fn})
+}
+
+// the commands received in the order received, semicolon separated commands are split
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *Server) backend(name string) *Backend {
+	for _, b := range s.backends {
+		if b.Name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *Server) server(backend, name string) *BackendServer {
+	b := s.backend(backend)
+	if b == nil {
+		return nil
+	}
+	for _, srv := range b.Servers {
+		if srv.Name == name {
+			return srv
+		}
+	}
+	return nil
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// serve a connection as HA-Proxy does, a single command line unless switched to interactive mode
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	interactive := false
+	level := levelAdmin
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		// a line ending with << is followed by a payload for the last command ending with an empty line
+		line = strings.TrimRight(line, "\r\n")
+		payload, hasPayload := "", strings.HasSuffix(line, "<<")
+		if hasPayload {
+			if payload, err = readPayload(reader); err != nil {
+				return
+			}
+		}
+
+		var out strings.Builder
+		cmds := strings.Split(line, ";")
+		for i, cmd := range cmds {
+			args := strings.Fields(cmd)
+			if len(args) == 0 {
+				continue
+			}
+			s.record(strings.Join(args, " "))
+			if hasPayload && i == len(cmds)-1 {
+				args = append(args, payload)
+			}
+
+			switch args[0] {
+			case "quit":
+				return
+			case "prompt":
+				interactive = !interactive
+			case "operator":
+				if level > levelOperator {
+					level = levelOperator
+				}
+			case "user":
+				level = levelUser
+			default:
+				out.WriteString(s.execute(args, level))
+			}
+		}
+
+		if interactive {
+			out.WriteString("\n> ")
+		} else {
+			out.WriteString("\n")
+		}
+		if _, err := conn.Write([]byte(out.String())); err != nil {
+			return
+		}
+
+		// a connection not in interactive mode is closed when there are no pipelined commands
+		if !interactive && reader.Buffered() == 0 {
+			return
+		}
+	}
+}
+
+// read the lines of a payload up to the empty line ending it
+func readPayload(reader *bufio.Reader) (string, error) {
+	var payload strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimRight(line, "\r\n") == "" {
+			return payload.String(), nil
+		}
+		payload.WriteString(line)
+	}
+}
+
+func (s *Server) record(cmd string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, cmd)
+}
+
+// execute a command returning the output, non empty output always ends with a line feed
+func (s *Server) execute(args []string, level int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := ""
+	if fn := s.scripted(args); fn != nil {
+		out = fn(args)
+	} else if cmd := lookupCommand(args); cmd != nil {
+		if level < cmd.level {
+			out = "Permission denied\n"
+		} else {
+			out = cmd.fn(s, args)
+		}
+	} else {
+		out = fmt.Sprintf("Unknown command: '%s', but maybe one of the following ones is a better match:\n", args[0])
+	}
+
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return out
+}
+
+func (s *Server) scripted(args []string) HandlerFunc {
+	for i := len(s.handlers) - 1; i >= 0; i-- {
+		if hasPrefix(args, s.handlers[i].words) {
+			return s.handlers[i].fn
+		}
+	}
+	return nil
+}
+
+func lookupCommand(args []string) *command {
+	var found *command
+	for i := range builtins {
+		cmd := &builtins[i]
+		// the longest matching command wins, e.g. show servers state over show servers
+		if hasPrefix(args, cmd.words) && (found == nil || len(cmd.words) > len(found.words)) {
+			found = cmd
+		}
+	}
+	return found
+}
+
+func hasPrefix(args, words []string) bool {
+	if len(words) > len(args) {
+		return false
+	}
+	for i, w := range words {
+		if args[i] != w {
+			return false
+		}
+	}
+	return true
+}
+
+// split a <backend>/<server> argument
+func splitServer(arg string) (string, string, bool) {
+	i := strings.IndexByte(arg, '/')
+	if i < 0 {
+		return "", "", false
+	}
+	return arg[:i], arg[i+1:], true
+}
+
+// look up the server of a <backend>/<server> argument returning the HA-Proxy error message when missing
+func (s *Server) lookup(arg string) (*BackendServer, string) {
+	backend, name, ok := splitServer(arg)
+	if !ok {
+		return nil, "Require 'backend/server'.\n"
+	}
+	if s.backend(backend) == nil {
+		return nil, "No such backend.\n"
+	}
+	srv := s.server(backend, name)
+	if srv == nil {
+		return nil, "No such server.\n"
+	}
+	return srv, ""
+}
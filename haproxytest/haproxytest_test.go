@@ -0,0 +1,283 @@
+package haproxytest
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// send a command line on a new connection returning the response
+func send(t *testing.T, network, address, line string) string {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		t.Fatalf("unable to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(line + "\n")); err != nil {
+		t.Fatalf("unable to write: %v", err)
+	}
+	resp, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("unable to read: %v", err)
+	}
+	return string(resp)
+}
+
+func newServer() *Server {
+	srv := NewServer()
+	srv.AddServer("indexws", "iws01", "172.24.21.40", 8080)
+	srv.AddServer("indexws", "iws02", "172.24.21.41", 8080)
+	return srv
+}
+
+func TestSetServerState(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	address := strings.TrimPrefix(srv.URI, "tcp://")
+
+	if resp := send(t, "tcp", address, "set server indexws/iws01 state drain"); resp != "\n" {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+	if s, _ := srv.LookupServer("indexws", "iws01"); s.State != "drain" {
+		t.Fatalf("state not drain: %s", s.State)
+	}
+
+	tests := map[string]string{
+		"set server indexws/nope state drain":            "No such server.\n\n",
+		"set server nope/iws01 state drain":              "No such backend.\n\n",
+		"set server indexws/iws01 state broken":          "'set server <srv> state' expects 'ready', 'drain' and 'maint'.\n\n",
+		"operator; set server indexws/iws01 state ready": "Permission denied\n\n",
+	}
+	for line, expected := range tests {
+		if resp := send(t, "tcp", address, line); resp != expected {
+			t.Fatalf("unexpected response to %s: %q", line, resp)
+		}
+	}
+}
+
+func TestSetServerAddr(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	address := strings.TrimPrefix(srv.URI, "tcp://")
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"set server indexws/iws01 addr 172.24.21.50 port 8081", "IP changed from '172.24.21.40' to '172.24.21.50', port changed from '8080' to '8081' by 'stats socket command'\n\n"},
+		{"set server indexws/iws01 addr 172.24.21.50", "no need to change the addr by 'stats socket command'\n\n"},
+		{"set server indexws/iws01 addr bad", "Could not understand IP address format.\n\n"},
+		{"set server indexws/iws01 addr", "set server <b>/<s> addr requires an address and optionally a port.\n\n"},
+		{"set server indexws/iws01 fqdn iws01.example.com", "indexws/iws01 changed its FQDN from (null) to iws01.example.com by 'stats socket command'\n\n"},
+		{"set server indexws/iws01 fqdn iws01.example.com", "no need to change the FDQN by 'stats socket command'\n\n"},
+	}
+	for _, test := range tests {
+		if resp := send(t, "tcp", address, test.line); resp != test.expected {
+			t.Fatalf("unexpected response to %s: %q", test.line, resp)
+		}
+	}
+	if s, _ := srv.LookupServer("indexws", "iws01"); s.Addr != "172.24.21.50" || s.Port != 8081 {
+		t.Fatalf("server not changed: %s:%d", s.Addr, s.Port)
+	}
+}
+
+func TestAddDelServer(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	address := strings.TrimPrefix(srv.URI, "tcp://")
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"add server indexws/iws03 172.24.21.42:8080 check weight 10", "New server registered.\n\n"},
+		{"add server indexws/iws03 172.24.21.42:8080", "Already exists a server with the same name in backend.\n\n"},
+		{"add server nope/iws03 172.24.21.42:8080", "No such backend.\n\n"},
+		{"del server indexws/iws01", "Only servers in maintenance mode can be deleted.\n\n"},
+		{"del server indexws/iws03", "Server deleted.\n\n"},
+		{"del server indexws/iws03", "No such server.\n\n"},
+	}
+	for _, test := range tests {
+		if resp := send(t, "tcp", address, test.line); resp != test.expected {
+			t.Fatalf("unexpected response to %s: %q", test.line, resp)
+		}
+	}
+}
+
+func TestChecks(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	address := strings.TrimPrefix(srv.URI, "tcp://")
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"disable health indexws/iws01", "\n"},
+		{"enable agent indexws/iws01", "Agent was not configured on this server, cannot enable.\n\n"},
+		{"set server indexws/iws01 agent up", "agent checks are not enabled on this server.\n\n"},
+		{"set server indexws/iws01 health down", "\n"},
+		{"set server indexws/iws01 health broken", "'set server <srv> health' expects 'up', 'stopping', or 'down'.\n\n"},
+		{"set server indexws/iws01 check-port 9000", "health check port updated.\n\n"},
+		{"set server indexws/iws01 check-addr 172.24.22.40", "health check addr updated.\n\n"},
+	}
+	for _, test := range tests {
+		if resp := send(t, "tcp", address, test.line); resp != test.expected {
+			t.Fatalf("unexpected response to %s: %q", test.line, resp)
+		}
+	}
+	s, _ := srv.LookupServer("indexws", "iws01")
+	if s.Health.Enabled || s.Status != "down" || s.Health.Port != 9000 || s.Health.Addr != "172.24.22.40" {
+		t.Fatalf("unexpected server: %+v", s)
+	}
+
+	// the agent send string is the first word as the words are split by the command line
+	srv.UpdateServer("indexws", "iws01", func(s *BackendServer) { s.Agent.Configured = true })
+	if resp := send(t, "tcp", address, "set server indexws/iws01 agent-send status ready"); resp != "agent send string updated.\n\n" {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+	if s, _ := srv.LookupServer("indexws", "iws01"); s.Agent.Send != "status" {
+		t.Fatalf("agent send not the first word: %q", s.Agent.Send)
+	}
+}
+
+func TestSSLCert(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	srv.AddCert("/etc/haproxy/iws.pem", "old\n")
+	address := strings.TrimPrefix(srv.URI, "tcp://")
+
+	// the payload follows the command line and ends with an empty line
+	payload := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	resp := send(t, "tcp", address, "set ssl cert /etc/haproxy/iws.pem <<\n"+payload)
+	if resp != "Transaction created for certificate /etc/haproxy/iws.pem!\n\n" {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+	if cert, _ := srv.Cert("/etc/haproxy/iws.pem"); cert != "old\n" {
+		t.Fatalf("certificate changed before commit: %q", cert)
+	}
+	if resp := send(t, "tcp", address, "commit ssl cert /etc/haproxy/iws.pem"); !strings.Contains(resp, "Success!") {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+	if cert, _ := srv.Cert("/etc/haproxy/iws.pem"); cert != payload {
+		t.Fatalf("certificate not committed: %q", cert)
+	}
+
+	tests := map[string]string{
+		"set ssl cert /etc/haproxy/nope.pem <<\n" + payload: "Can't replace a certificate which is not referenced by the configuration!\n\n",
+		"commit ssl cert /etc/haproxy/iws.pem":              "No ongoing transaction! !\n\n",
+		"abort ssl cert /etc/haproxy/iws.pem":               "No ongoing transaction!\n\n",
+		"operator; commit ssl cert /etc/haproxy/iws.pem":    "Permission denied\n\n",
+	}
+	for line, expected := range tests {
+		if resp := send(t, "tcp", address, line); resp != expected {
+			t.Fatalf("unexpected response to %s: %q", line, resp)
+		}
+	}
+}
+
+func TestShowStat(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	srv.UpdateServer("indexws", "iws01", func(s *BackendServer) {
+		s.Scur = 3
+		s.Stats["hrsp_5xx"] = "7"
+	})
+
+	resp := send(t, "tcp", strings.TrimPrefix(srv.URI, "tcp://"), "show stat")
+	lines := strings.Split(resp, "\n")
+	if lines[0] != statHeader {
+		t.Fatalf("unexpected header: %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "indexws,iws01,,,3,") || !strings.Contains(lines[1], ",7,") {
+		t.Fatalf("unexpected server row: %s", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "indexws,BACKEND,,,3,") {
+		t.Fatalf("unexpected backend row: %s", lines[3])
+	}
+	if !strings.HasSuffix(resp, "\n\n") {
+		t.Fatalf("response not terminated by an empty line")
+	}
+}
+
+func TestShowInfo(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	srv.UpdateServer("indexws", "iws01", func(s *BackendServer) { s.Scur = 3 })
+	srv.SetInfo("Nbthread", "4")
+	srv.SetInfo("QuicConns", "2")
+
+	resp := send(t, "tcp", strings.TrimPrefix(srv.URI, "tcp://"), "show info")
+	if !strings.HasPrefix(resp, "Name: HAProxy\nVersion: 2.6.10\n") {
+		t.Fatalf("unexpected beginning of show info: %s", resp)
+	}
+	for _, line := range []string{"Nbthread: 4\n", "CurrConns: 3\n", "QuicConns: 2\n\n"} {
+		if !strings.Contains(resp, line) {
+			t.Fatalf("expected %q in show info: %s", line, resp)
+		}
+	}
+
+	resp = send(t, "tcp", strings.TrimPrefix(srv.URI, "tcp://"), "show info json")
+	for _, field := range []string{
+		`{"field":{"name":"Name","pos":0},"processNum":1,`,
+		`"value":{"type":"str","value":"HAProxy"}}`,
+		`"value":{"type":"u64","value":4}}`,
+	} {
+		if !strings.Contains(resp, field) {
+			t.Fatalf("expected %q in show info json: %s", field, resp)
+		}
+	}
+}
+
+func TestShowServersState(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	srv.UpdateServer("indexws", "iws02", func(s *BackendServer) { s.State = "maint" })
+
+	resp := send(t, "tcp", strings.TrimPrefix(srv.URI, "tcp://"), "show servers state indexws")
+	expected := "1\n" + serversStateHeader + "\n" +
+		"1 indexws 1 iws01 172.24.21.40 2 0 1 1 0 15 3 4 6 0 0 0 - 8080 - 0 0 - - 0\n" +
+		"1 indexws 2 iws02 172.24.21.41 0 1 1 1 0 15 3 4 6 0 0 0 - 8080 - 0 0 - - 0\n\n"
+	if resp != expected {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+
+	if resp := send(t, "tcp", strings.TrimPrefix(srv.URI, "tcp://"), "show servers state nope"); resp != "Can't find backend.\n\n" {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+}
+
+func TestInteractiveUnixServer(t *testing.T) {
+	srv := NewUnixServer(filepath.Join(t.TempDir(), "admin.sock"))
+	defer srv.Close()
+	srv.Handle("show servers conn", "# bkname/svname bkid/svid")
+
+	conn, err := net.Dial("unix", strings.TrimPrefix(srv.URI, "unix://"))
+	if err != nil {
+		t.Fatalf("unable to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("prompt\nshow servers conn; show nothing\n")); err != nil {
+		t.Fatalf("unable to write: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	expected := "\n> # bkname/svname bkid/svid\nUnknown command: 'show', but maybe one of the following ones is a better match:\n\n> "
+	buf := make([]byte, len(expected))
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		t.Fatalf("unable to read: %v", err)
+	}
+	if string(buf) != expected {
+		t.Fatalf("unexpected response: %q", buf)
+	}
+
+	commands := srv.Commands()
+	if len(commands) != 3 || commands[1] != "show servers conn" || commands[2] != "show nothing" {
+		t.Fatalf("unexpected commands: %q", commands)
+	}
+}
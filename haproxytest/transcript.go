@@ -0,0 +1,218 @@
+package haproxytest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dialer connects to a stats socket, the method set matches the Dialer of the client
+// so a Recorder and a Replayer can be given to the client with the WithDialer option
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Exchange is a command sent to the Runtime API and the raw response received
+type Exchange struct {
+	Command  string        `json:"command"`  // the command line without the trailing line feed
+	Response string        `json:"response"` // the raw response including the terminating empty line
+	Duration time.Duration `json:"duration"` // time from sending the command until the response was read
+}
+
+// Transcript of exchanges with a Runtime API stored as JSON
+type Transcript struct {
+	Version   string     `json:"version,omitempty"` // HA-Proxy version the transcript was recorded from
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+// load a transcript saved with Save
+func LoadTranscript(path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("unable to parse transcript %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// save the transcript as indented JSON
+func (t *Transcript) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Recorder is a Dialer recording the exchanges on the connections of another Dialer.
+// Each connection is recorded as one exchange, which is how commands are executed by
+// a client without a pool. Commands sent over interactive sessions are recorded as a
+// single exchange for the whole session.
+type Recorder struct {
+	dialer Dialer
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// record the exchanges on connections made by dialer, nil records from a net.Dialer
+func NewRecorder(dialer Dialer) *Recorder {
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	return &Recorder{dialer: dialer}
+}
+
+func (r *Recorder) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := r.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{Conn: conn, recorder: r}, nil
+}
+
+// the transcript of the connections closed so far
+func (r *Recorder) Transcript() *Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Transcript{Exchanges: append([]Exchange(nil), r.exchanges...)}
+}
+
+func (r *Recorder) record(e Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, e)
+}
+
+// connection capturing everything written and read
+type recordingConn struct {
+	net.Conn
+	recorder *Recorder
+
+	mu       sync.Mutex
+	command  bytes.Buffer
+	response bytes.Buffer
+	started  time.Time
+	finished time.Time
+	closed   bool
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	if c.started.IsZero() {
+		c.started = time.Now()
+	}
+	c.command.Write(b)
+	c.mu.Unlock()
+	return c.Conn.Write(b)
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.mu.Lock()
+	c.response.Write(b[:n])
+	if n > 0 {
+		c.finished = time.Now()
+	}
+	c.mu.Unlock()
+	return n, err
+}
+
+// close the connection and record the exchange
+func (c *recordingConn) Close() error {
+	c.mu.Lock()
+	if !c.closed && c.command.Len() > 0 {
+		c.closed = true
+		c.recorder.record(Exchange{
+			Command:  strings.TrimRight(c.command.String(), "\r\n"),
+			Response: c.response.String(),
+			Duration: c.finished.Sub(c.started),
+		})
+	}
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// Replayer is a Dialer answering commands from a transcript over in-memory connections.
+// Exchanges recorded for the same command are replayed in the recorded order, and the
+// last of them is repeated when they have all been replayed. Commands not found in the
+// transcript are answered as unknown commands.
+type Replayer struct {
+	Timing bool // wait the recorded duration before responding
+
+	transcript *Transcript
+
+	mu       sync.Mutex
+	replayed map[string]int
+}
+
+// replay the exchanges of the transcript
+func NewReplayer(t *Transcript) *Replayer {
+	return &Replayer{
+		transcript: t,
+		replayed:   make(map[string]int),
+	}
+}
+
+func (r *Replayer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	client, server := net.Pipe()
+	go r.serve(server)
+	return client, nil
+}
+
+// answer a single command on the connection
+func (r *Replayer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	command := strings.TrimRight(line, "\r\n")
+
+	e, ok := r.next(command)
+	if !ok {
+		fmt.Fprintf(conn, "Unknown command: '%s' is not in the transcript\n\n", command)
+		return
+	}
+	if r.Timing {
+		time.Sleep(e.Duration)
+	}
+	conn.Write([]byte(e.Response))
+}
+
+// the next exchange to replay for the command
+func (r *Replayer) next(command string) (Exchange, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matching []Exchange
+	for _, e := range r.transcript.Exchanges {
+		if e.Command == command {
+			matching = append(matching, e)
+		}
+	}
+	if len(matching) == 0 {
+		return Exchange{}, false
+	}
+
+	i := r.replayed[command]
+	if i >= len(matching) {
+		i = len(matching) - 1
+	}
+	r.replayed[command]++
+	return matching[i], true
+}
@@ -0,0 +1,82 @@
+package haproxytest_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	haproxy "github.com/industria/haproxy-runtime-api-client"
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := haproxytest.NewServer()
+	defer srv.Close()
+	srv.AddServer("indexws", "iws01", "172.24.21.40", 8080)
+
+	recorder := haproxytest.NewRecorder(nil)
+	client, err := haproxy.NewClient(srv.URI, haproxy.WithDialer(recorder))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	if err := client.SetServerState("indexws", "iws01", haproxy.ServerStateDrain); err != nil {
+		t.Fatalf("unable to drain: %v", err)
+	}
+	recorded, err := client.ShowStat()
+	if err != nil {
+		t.Fatalf("unable to show stat: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "transcript.json")
+	if err := recorder.Transcript().Save(path); err != nil {
+		t.Fatalf("unable to save transcript: %v", err)
+	}
+	transcript, err := haproxytest.LoadTranscript(path)
+	if err != nil {
+		t.Fatalf("unable to load transcript: %v", err)
+	}
+	if len(transcript.Exchanges) != 2 || transcript.Exchanges[0].Command != "set server indexws/iws01 state drain" {
+		t.Fatalf("unexpected exchanges: %+v", transcript.Exchanges)
+	}
+
+	// the fake server is no longer needed when replaying
+	srv.Close()
+	replay, err := haproxy.NewClient(srv.URI, haproxy.WithDialer(haproxytest.NewReplayer(transcript)))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	replayed, err := replay.ShowStat()
+	if err != nil {
+		t.Fatalf("unable to replay show stat: %v", err)
+	}
+	if len(replayed) != len(recorded) || replayed[0].Status != "DRAIN" {
+		t.Fatalf("unexpected replayed stat: %+v", replayed)
+	}
+
+	if _, err := replay.Execute("show info"); err != nil {
+		t.Fatalf("unable to execute show info: %v", err)
+	}
+	if _, err := replay.ShowServersState(); err == nil {
+		t.Fatalf("expected command missing from the transcript to fail")
+	}
+}
+
+func TestReplayInOrder(t *testing.T) {
+	transcript := &haproxytest.Transcript{Exchanges: []haproxytest.Exchange{
+		{Command: "show version", Response: "2.6.10\n\n"},
+		{Command: "show version", Response: "2.6.11\n\n"},
+	}}
+	client, err := haproxy.NewClient("unix:///run/haproxy/admin.sock", haproxy.WithDialer(haproxytest.NewReplayer(transcript)))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	for _, expected := range []string{"2.6.10\n\n", "2.6.11\n\n", "2.6.11\n\n"} {
+		resp, err := client.Execute("show version")
+		if err != nil {
+			t.Fatalf("unable to execute show version: %v", err)
+		}
+		if string(resp) != expected {
+			t.Fatalf("expected %q got %q", expected, resp)
+		}
+	}
+}
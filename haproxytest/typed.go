@@ -0,0 +1,157 @@
+package haproxytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// origin, nature and scope tags and value type of the show stat fields set by the fake
+// server as reported by HA-Proxy 2.6, fields only set through the Stats of a server are reported as metric gauges
+var statFieldTypes = map[string]struct {
+	tags      string
+	valueType string
+}{
+	"pxname":       {"KNS", "str"},
+	"svname":       {"KNS", "str"},
+	"scur":         {"MGP", "u32"},
+	"stot":         {"MCP", "u64"},
+	"status":       {"SGP", "str"},
+	"weight":       {"MaP", "u32"},
+	"act":          {"SGP", "u32"},
+	"bck":          {"SGP", "u32"},
+	"pid":          {"KGP", "u32"},
+	"iid":          {"KGS", "u32"},
+	"sid":          {"KGS", "u32"},
+	"type":         {"CGS", "u32"},
+	"check_status": {"MOP", "str"},
+	"check_code":   {"MOP", "u32"},
+	"addr":         {"CGS", "str"},
+	"mode":         {"CGS", "str"},
+	"algo":         {"CGS", "str"},
+	"uweight":      {"MaP", "u32"},
+}
+
+// names of the tags in the json output
+var (
+	jsonObjectTypes = map[string]string{"F": "Frontend", "B": "Backend", "S": "Server", "L": "Listener"}
+	jsonOrigins     = map[byte]string{'M': "Metric", 'S': "Status", 'K': "Key", 'C': "Config", 'P': "Product"}
+	jsonNatures     = map[byte]string{'A': "Age", 'a': "Avg", 'C': "Counter", 'D': "Duration", 'G': "Gauge", 'L': "Limit", 'M': "Max", 'N': "Name", 'O': "Output", 'R': "Rate", 'T': "Time"}
+	jsonScopes      = map[byte]string{'G': "Global", 'P': "Process", 'S': "Service", 's': "System"}
+)
+
+// a field of a show stat row in the typed and json formats
+type statField struct {
+	object    string // F, B, S or L
+	proxyID   int
+	objectID  int
+	position  int
+	name      string
+	tags      string
+	valueType string
+	value     string
+}
+
+// the fields of a row with a value, empty fields are left out as done by HA-Proxy
+func statFields(columns []string, row map[string]string) []statField {
+	object := "S"
+	switch row["type"] {
+	case "0":
+		object = "F"
+	case "1":
+		object = "B"
+	case "3":
+		object = "L"
+	}
+	proxyID, _ := strconv.Atoi(row["iid"])
+	objectID, _ := strconv.Atoi(row["sid"])
+
+	var fields []statField
+	for i, c := range columns {
+		v := row[c]
+		if v == "" || c == "-" {
+			continue
+		}
+		t, ok := statFieldTypes[c]
+		if !ok {
+			t.tags = "MGP"
+			t.valueType = "u64"
+			if _, err := strconv.ParseUint(v, 10, 64); err != nil {
+				t.valueType = "str"
+			}
+		}
+		fields = append(fields, statField{object, proxyID, objectID, i, c, t.tags, t.valueType, v})
+	}
+	return fields
+}
+
+// show stat typed
+func typedStat(columns []string, rows []map[string]string) string {
+	var out strings.Builder
+	for _, row := range rows {
+		for _, f := range statFields(columns, row) {
+			fmt.Fprintf(&out, "%s.%d.%d.%d.%s.1:%s:%s:%s\n",
+				f.object, f.proxyID, f.objectID, f.position, f.name, f.tags, f.valueType, f.value)
+		}
+	}
+	return out.String()
+}
+
+// show stat json
+func jsonStat(columns []string, rows []map[string]string) string {
+	objects := make([][]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		var object []map[string]any
+		for _, f := range statFields(columns, row) {
+			var value any = f.value
+			if f.valueType != "str" {
+				value = json.Number(f.value)
+			}
+			object = append(object, map[string]any{
+				"objType":    jsonObjectTypes[f.object],
+				"proxyId":    f.proxyID,
+				"id":         f.objectID,
+				"field":      map[string]any{"pos": f.position, "name": f.name},
+				"processNum": 1,
+				"tags": map[string]string{
+					"origin": jsonOrigins[f.tags[0]],
+					"nature": jsonNatures[f.tags[1]],
+					"scope":  jsonScopes[f.tags[2]],
+				},
+				"value": map[string]any{"type": f.valueType, "value": value},
+			})
+		}
+		objects = append(objects, object)
+	}
+
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return fmt.Sprintf(`{"errorStr":%q}`, err.Error())
+	}
+	return string(data)
+}
+
+// show info json, numbers are written as json numbers and other values as strings
+func jsonInfo(names []string, values map[string]string) string {
+	fields := make([]map[string]any, 0, len(names))
+	for i, name := range names {
+		var value any = values[name]
+		valueType := "str"
+		if _, err := strconv.ParseUint(values[name], 10, 64); err == nil {
+			value, valueType = json.Number(values[name]), "u64"
+		}
+		fields = append(fields, map[string]any{
+			"field":      map[string]any{"pos": i, "name": name},
+			"processNum": 1,
+			"tags":       map[string]string{"origin": "Metric", "nature": "Gauge", "scope": "Process"},
+			"value":      map[string]any{"type": valueType, "value": value},
+		})
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Sprintf(`{"errorStr":%q}`, err.Error())
+	}
+	return string(data)
+}
@@ -0,0 +1,6 @@
+package info
+
+import "github.com/industria/haproxy-runtime-api-client/internal/parse"
+
+// ParseError reports a value in a response which could not be parsed, the Column is the name of the field
+type ParseError = parse.Error
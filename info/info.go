@@ -0,0 +1,251 @@
+// package for working with process information
+package info
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// represents the output of the command show info as written by HA-Proxy 2.6
+// Reference: http://docs.haproxy.org/2.6/management.html#9.3-show%20info
+// the fields are matched by name and fields not mapped like those of later versions are found in Extra
+type Info struct {
+	Name                       string // Name: product name
+	Version                    string // Version: HA-Proxy version
+	ReleaseDate                string // Release_date: release date of the version
+	Nbthread                   int    // Nbthread: number of started threads
+	Nbproc                     int    // Nbproc: number of started worker processes (historical, always 1)
+	ProcessNum                 int    // Process_num: relative process number (historical, always 1)
+	Pid                        int    // Pid: process id of the process
+	Uptime                     string // Uptime: time since the process was started, e.g. 0d 1h02m03s
+	UptimeSec                  uint64 // Uptime_sec: time in seconds since the process was started
+	MemmaxMB                   uint64 // Memmax_MB: memory limit of the process in megabytes, 0 for no limit
+	PoolAllocMB                uint64 // PoolAlloc_MB: memory allocated in pools in megabytes
+	PoolUsedMB                 uint64 // PoolUsed_MB: memory used from the pools in megabytes
+	PoolFailed                 uint64 // PoolFailed: number of failed pool allocations
+	UlimitN                    uint64 // Ulimit-n: maximum number of open files of the process
+	Maxsock                    uint64 // Maxsock: maximum number of open sockets
+	Maxconn                    uint64 // Maxconn: maximum number of concurrent connections
+	HardMaxconn                uint64 // Hard_maxconn: initial maximum number of concurrent connections
+	CurrConns                  uint64 // CurrConns: current number of connections
+	CumConns                   uint64 // CumConns: total number of connections
+	CumReq                     uint64 // CumReq: total number of requests
+	MaxSslConns                uint64 // MaxSslConns: maximum number of concurrent SSL connections
+	CurrSslConns               uint64 // CurrSslConns: current number of SSL connections
+	CumSslConns                uint64 // CumSslConns: total number of SSL connections
+	Maxpipes                   uint64 // Maxpipes: maximum number of pipes
+	PipesUsed                  uint64 // PipesUsed: number of pipes in use
+	PipesFree                  uint64 // PipesFree: number of pipes unused
+	ConnRate                   uint64 // ConnRate: number of connections over the last second
+	ConnRateLimit              uint64 // ConnRateLimit: configured maximum number of connections per second
+	MaxConnRate                uint64 // MaxConnRate: highest ConnRate reached
+	SessRate                   uint64 // SessRate: number of sessions over the last second
+	SessRateLimit              uint64 // SessRateLimit: configured maximum number of sessions per second
+	MaxSessRate                uint64 // MaxSessRate: highest SessRate reached
+	SslRate                    uint64 // SslRate: number of SSL sessions over the last second
+	SslRateLimit               uint64 // SslRateLimit: configured maximum number of SSL sessions per second
+	MaxSslRate                 uint64 // MaxSslRate: highest SslRate reached
+	SslFrontendKeyRate         uint64 // SslFrontendKeyRate: number of SSL keys computed for frontends over the last second
+	SslFrontendMaxKeyRate      uint64 // SslFrontendMaxKeyRate: highest SslFrontendKeyRate reached
+	SslFrontendSessionReusePct uint64 // SslFrontendSessionReuse_pct: percentage of SSL sessions reused in frontends
+	SslBackendKeyRate          uint64 // SslBackendKeyRate: number of SSL keys computed for backends over the last second
+	SslBackendMaxKeyRate       uint64 // SslBackendMaxKeyRate: highest SslBackendKeyRate reached
+	SslCacheLookups            uint64 // SslCacheLookups: total number of SSL session cache lookups
+	SslCacheMisses             uint64 // SslCacheMisses: total number of SSL session cache misses
+	CompressBpsIn              uint64 // CompressBpsIn: bytes per second over last second before compression
+	CompressBpsOut             uint64 // CompressBpsOut: bytes per second over last second after compression
+	CompressBpsRateLim         uint64 // CompressBpsRateLim: configured maximum compression input rate
+	ZlibMemUsage               uint64 // ZlibMemUsage: memory used by zlib
+	MaxZlibMemUsage            uint64 // MaxZlibMemUsage: configured maximum memory for zlib
+	Tasks                      uint64 // Tasks: number of tasks
+	RunQueue                   uint64 // Run_queue: number of tasks in the run queue
+	IdlePct                    uint32 // Idle_pct: percentage of the time the process was idle
+	Node                       string // node: node name
+	Description                string // description: node description
+	Stopping                   bool   // Stopping: the process is stopping after a reload or a stop
+	Jobs                       uint64 // Jobs: number of active jobs (listeners, sessions and peers)
+	UnstoppableJobs            uint64 // Unstoppable Jobs: number of active jobs not stopping on a soft stop
+	Listeners                  uint64 // Listeners: number of listeners
+	ActivePeers                uint64 // ActivePeers: number of active peers
+	ConnectedPeers             uint64 // ConnectedPeers: number of connected peers
+	DroppedLogs                uint64 // DroppedLogs: total number of dropped log messages
+	BusyPolling                bool   // BusyPolling: busy polling is enabled
+	FailedResolutions          uint64 // FailedResolutions: total number of failed DNS resolutions
+	TotalBytesOut              uint64 // TotalBytesOut: total number of bytes sent
+	TotalSplicedBytesOut       uint64 // TotalSplicedBytesOut: total number of bytes sent by splicing (TotalSplicdedBytesOut in 2.6)
+	BytesOutRate               uint64 // BytesOutRate: number of bytes sent over the last second
+	DebugCommandsIssued        uint64 // DebugCommandsIssued: number of expert debug commands issued
+	CumRecvLogs                uint64 // CumRecvLogs: total number of log messages received by log forwarders
+	BuildInfo                  string // Build info: build options
+	MemmaxBytes                uint64 // Memmax_bytes: memory limit of the process in bytes, 0 for no limit
+	PoolAllocBytes             uint64 // PoolAlloc_bytes: memory allocated in pools in bytes
+	PoolUsedBytes              uint64 // PoolUsed_bytes: memory used from the pools in bytes
+	StartTimeSec               uint64 // Start_time_sec: start time of the process in seconds since the epoch
+	Tainted                    string // Tainted: hexadecimal mask of the reasons the process is tainted
+	TotalWarnings              uint64 // TotalWarnings: total number of warnings emitted
+	MaxconnReached             uint64 // MaxconnReached: number of times Maxconn was reached
+	BootTimeMs                 uint64 // BootTime_ms: time in milliseconds spent starting the process
+	Extra                      map[string]string
+}
+
+// the uptime of the process
+func (i *Info) UptimeDuration() time.Duration {
+	return time.Duration(i.UptimeSec) * time.Second
+}
+
+// the start time of the process
+func (i *Info) StartTime() time.Time {
+	return time.Unix(int64(i.StartTimeSec), 0)
+}
+
+// fields of show info mapped to the Info fields
+var fields = map[string]func(i *Info, v string) error{
+	"Name":                        func(i *Info, v string) (err error) { i.Name = v; return },
+	"Version":                     func(i *Info, v string) (err error) { i.Version = v; return },
+	"Release_date":                func(i *Info, v string) (err error) { i.ReleaseDate = v; return },
+	"Nbthread":                    func(i *Info, v string) (err error) { i.Nbthread, err = atoi(v); return },
+	"Nbproc":                      func(i *Info, v string) (err error) { i.Nbproc, err = atoi(v); return },
+	"Process_num":                 func(i *Info, v string) (err error) { i.ProcessNum, err = atoi(v); return },
+	"Pid":                         func(i *Info, v string) (err error) { i.Pid, err = atoi(v); return },
+	"Uptime":                      func(i *Info, v string) (err error) { i.Uptime = v; return },
+	"Uptime_sec":                  func(i *Info, v string) (err error) { i.UptimeSec, err = u64(v); return },
+	"Memmax_MB":                   func(i *Info, v string) (err error) { i.MemmaxMB, err = u64(v); return },
+	"PoolAlloc_MB":                func(i *Info, v string) (err error) { i.PoolAllocMB, err = u64(v); return },
+	"PoolUsed_MB":                 func(i *Info, v string) (err error) { i.PoolUsedMB, err = u64(v); return },
+	"PoolFailed":                  func(i *Info, v string) (err error) { i.PoolFailed, err = u64(v); return },
+	"Ulimit-n":                    func(i *Info, v string) (err error) { i.UlimitN, err = u64(v); return },
+	"Maxsock":                     func(i *Info, v string) (err error) { i.Maxsock, err = u64(v); return },
+	"Maxconn":                     func(i *Info, v string) (err error) { i.Maxconn, err = u64(v); return },
+	"Hard_maxconn":                func(i *Info, v string) (err error) { i.HardMaxconn, err = u64(v); return },
+	"CurrConns":                   func(i *Info, v string) (err error) { i.CurrConns, err = u64(v); return },
+	"CumConns":                    func(i *Info, v string) (err error) { i.CumConns, err = u64(v); return },
+	"CumReq":                      func(i *Info, v string) (err error) { i.CumReq, err = u64(v); return },
+	"MaxSslConns":                 func(i *Info, v string) (err error) { i.MaxSslConns, err = u64(v); return },
+	"CurrSslConns":                func(i *Info, v string) (err error) { i.CurrSslConns, err = u64(v); return },
+	"CumSslConns":                 func(i *Info, v string) (err error) { i.CumSslConns, err = u64(v); return },
+	"Maxpipes":                    func(i *Info, v string) (err error) { i.Maxpipes, err = u64(v); return },
+	"PipesUsed":                   func(i *Info, v string) (err error) { i.PipesUsed, err = u64(v); return },
+	"PipesFree":                   func(i *Info, v string) (err error) { i.PipesFree, err = u64(v); return },
+	"ConnRate":                    func(i *Info, v string) (err error) { i.ConnRate, err = u64(v); return },
+	"ConnRateLimit":               func(i *Info, v string) (err error) { i.ConnRateLimit, err = u64(v); return },
+	"MaxConnRate":                 func(i *Info, v string) (err error) { i.MaxConnRate, err = u64(v); return },
+	"SessRate":                    func(i *Info, v string) (err error) { i.SessRate, err = u64(v); return },
+	"SessRateLimit":               func(i *Info, v string) (err error) { i.SessRateLimit, err = u64(v); return },
+	"MaxSessRate":                 func(i *Info, v string) (err error) { i.MaxSessRate, err = u64(v); return },
+	"SslRate":                     func(i *Info, v string) (err error) { i.SslRate, err = u64(v); return },
+	"SslRateLimit":                func(i *Info, v string) (err error) { i.SslRateLimit, err = u64(v); return },
+	"MaxSslRate":                  func(i *Info, v string) (err error) { i.MaxSslRate, err = u64(v); return },
+	"SslFrontendKeyRate":          func(i *Info, v string) (err error) { i.SslFrontendKeyRate, err = u64(v); return },
+	"SslFrontendMaxKeyRate":       func(i *Info, v string) (err error) { i.SslFrontendMaxKeyRate, err = u64(v); return },
+	"SslFrontendSessionReuse_pct": func(i *Info, v string) (err error) { i.SslFrontendSessionReusePct, err = u64(v); return },
+	"SslBackendKeyRate":           func(i *Info, v string) (err error) { i.SslBackendKeyRate, err = u64(v); return },
+	"SslBackendMaxKeyRate":        func(i *Info, v string) (err error) { i.SslBackendMaxKeyRate, err = u64(v); return },
+	"SslCacheLookups":             func(i *Info, v string) (err error) { i.SslCacheLookups, err = u64(v); return },
+	"SslCacheMisses":              func(i *Info, v string) (err error) { i.SslCacheMisses, err = u64(v); return },
+	"CompressBpsIn":               func(i *Info, v string) (err error) { i.CompressBpsIn, err = u64(v); return },
+	"CompressBpsOut":              func(i *Info, v string) (err error) { i.CompressBpsOut, err = u64(v); return },
+	"CompressBpsRateLim":          func(i *Info, v string) (err error) { i.CompressBpsRateLim, err = u64(v); return },
+	"ZlibMemUsage":                func(i *Info, v string) (err error) { i.ZlibMemUsage, err = u64(v); return },
+	"MaxZlibMemUsage":             func(i *Info, v string) (err error) { i.MaxZlibMemUsage, err = u64(v); return },
+	"Tasks":                       func(i *Info, v string) (err error) { i.Tasks, err = u64(v); return },
+	"Run_queue":                   func(i *Info, v string) (err error) { i.RunQueue, err = u64(v); return },
+	"Idle_pct":                    func(i *Info, v string) (err error) { i.IdlePct, err = u32(v); return },
+	"node":                        func(i *Info, v string) (err error) { i.Node = v; return },
+	"description":                 func(i *Info, v string) (err error) { i.Description = v; return },
+	"Stopping":                    func(i *Info, v string) (err error) { i.Stopping, err = atob(v); return },
+	"Jobs":                        func(i *Info, v string) (err error) { i.Jobs, err = u64(v); return },
+	"Unstoppable Jobs":            func(i *Info, v string) (err error) { i.UnstoppableJobs, err = u64(v); return },
+	"Listeners":                   func(i *Info, v string) (err error) { i.Listeners, err = u64(v); return },
+	"ActivePeers":                 func(i *Info, v string) (err error) { i.ActivePeers, err = u64(v); return },
+	"ConnectedPeers":              func(i *Info, v string) (err error) { i.ConnectedPeers, err = u64(v); return },
+	"DroppedLogs":                 func(i *Info, v string) (err error) { i.DroppedLogs, err = u64(v); return },
+	"BusyPolling":                 func(i *Info, v string) (err error) { i.BusyPolling, err = atob(v); return },
+	"FailedResolutions":           func(i *Info, v string) (err error) { i.FailedResolutions, err = u64(v); return },
+	"TotalBytesOut":               func(i *Info, v string) (err error) { i.TotalBytesOut, err = u64(v); return },
+	"TotalSplicedBytesOut":        func(i *Info, v string) (err error) { i.TotalSplicedBytesOut, err = u64(v); return },
+	"TotalSplicdedBytesOut":       func(i *Info, v string) (err error) { i.TotalSplicedBytesOut, err = u64(v); return },
+	"BytesOutRate":                func(i *Info, v string) (err error) { i.BytesOutRate, err = u64(v); return },
+	"DebugCommandsIssued":         func(i *Info, v string) (err error) { i.DebugCommandsIssued, err = u64(v); return },
+	"CumRecvLogs":                 func(i *Info, v string) (err error) { i.CumRecvLogs, err = u64(v); return },
+	"Build info":                  func(i *Info, v string) (err error) { i.BuildInfo = v; return },
+	"Memmax_bytes":                func(i *Info, v string) (err error) { i.MemmaxBytes, err = u64(v); return },
+	"PoolAlloc_bytes":             func(i *Info, v string) (err error) { i.PoolAllocBytes, err = u64(v); return },
+	"PoolUsed_bytes":              func(i *Info, v string) (err error) { i.PoolUsedBytes, err = u64(v); return },
+	"Start_time_sec":              func(i *Info, v string) (err error) { i.StartTimeSec, err = u64(v); return },
+	"Tainted":                     func(i *Info, v string) (err error) { i.Tainted = v; return },
+	"TotalWarnings":               func(i *Info, v string) (err error) { i.TotalWarnings, err = u64(v); return },
+	"MaxconnReached":              func(i *Info, v string) (err error) { i.MaxconnReached, err = u64(v); return },
+	"BootTime_ms":                 func(i *Info, v string) (err error) { i.BootTimeMs, err = u64(v); return },
+}
+
+// parse the response of the command show info from the Runtime API
+// where each line is a field in the form <name>: <value>
+// a line which can not be parsed is returned as a *ParseError
+func ParseShowInfo(response []byte) (*Info, error) {
+	info := &Info{}
+	scanner := bufio.NewScanner(bytes.NewReader(response))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		name, value, found := strings.Cut(text, ":")
+		if !found {
+			return nil, &ParseError{Line: line, Value: text, Err: errors.New("expected <name>: <value>")}
+		}
+		value = strings.TrimSpace(value)
+		if err := info.Set(name, value); err != nil {
+			return nil, &ParseError{Line: line, Column: name, Value: value, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// set the field by the name used in show info, names not mapped are kept in Extra
+func (i *Info) Set(name, value string) error {
+	if set, ok := fields[name]; ok {
+		return set(i, value)
+	}
+	if i.Extra == nil {
+		i.Extra = make(map[string]string)
+	}
+	i.Extra[name] = value
+	return nil
+}
+
+func atoi(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func atob(s string) (bool, error) {
+	if len(s) == 0 {
+		return false, nil
+	}
+	x, err := strconv.Atoi(s)
+	return x != 0, err
+}
+
+func u64(s string) (uint64, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func u32(s string) (uint32, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	x, err := strconv.ParseUint(s, 10, 32)
+	return uint32(x), err
+}
@@ -0,0 +1,138 @@
+package info
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// show info from HA-Proxy 2.6.10
+const showInfo = `Name: HAProxy
+Version: 2.6.10-1ppa1~jammy
+Release_date: 2023/03/10
+Nbthread: 4
+Nbproc: 1
+Process_num: 1
+Pid: 1822
+Uptime: 0d 1h02m03s
+Uptime_sec: 3723
+Memmax_MB: 0
+PoolAlloc_MB: 0
+PoolUsed_MB: 0
+PoolFailed: 0
+Ulimit-n: 200045
+Maxsock: 200045
+Maxconn: 100000
+Hard_maxconn: 100000
+CurrConns: 12
+CumConns: 7315
+CumReq: 18207
+MaxSslConns: 0
+CurrSslConns: 3
+CumSslConns: 63
+Maxpipes: 0
+PipesUsed: 0
+PipesFree: 0
+ConnRate: 2
+ConnRateLimit: 0
+MaxConnRate: 41
+SessRate: 2
+SessRateLimit: 0
+MaxSessRate: 41
+SslRate: 1
+SslRateLimit: 0
+MaxSslRate: 9
+SslFrontendKeyRate: 0
+SslFrontendMaxKeyRate: 9
+SslFrontendSessionReuse_pct: 70
+SslBackendKeyRate: 0
+SslBackendMaxKeyRate: 0
+SslCacheLookups: 44
+SslCacheMisses: 0
+CompressBpsIn: 0
+CompressBpsOut: 0
+CompressBpsRateLim: 0
+Tasks: 131
+Run_queue: 1
+Idle_pct: 98
+node: lb01
+Stopping: 0
+Jobs: 17
+Unstoppable Jobs: 0
+Listeners: 5
+ActivePeers: 0
+ConnectedPeers: 0
+DroppedLogs: 0
+BusyPolling: 0
+FailedResolutions: 0
+TotalBytesOut: 44806716
+TotalSplicdedBytesOut: 0
+BytesOutRate: 1536
+DebugCommandsIssued: 0
+CumRecvLogs: 0
+Build info: 2.6.10-1ppa1~jammy
+Memmax_bytes: 0
+PoolAlloc_bytes: 1241184
+PoolUsed_bytes: 1241184
+Start_time_sec: 1678440000
+Tainted: 0
+TotalWarnings: 2
+MaxconnReached: 0
+BootTime_ms: 21
+
+`
+
+func TestParseShowInfo(t *testing.T) {
+	info, err := ParseShowInfo([]byte(showInfo))
+	if err != nil {
+		t.Fatalf("unable to parse show info: %v", err)
+	}
+	if info.Name != "HAProxy" || info.Version != "2.6.10-1ppa1~jammy" || info.ReleaseDate != "2023/03/10" {
+		t.Fatalf("unexpected version: %+v", info)
+	}
+	if info.Nbthread != 4 || info.Pid != 1822 || info.Maxconn != 100000 || info.UlimitN != 200045 {
+		t.Fatalf("unexpected process: %+v", info)
+	}
+	if info.CurrConns != 12 || info.CumReq != 18207 || info.SslRate != 1 || info.Tasks != 131 || info.RunQueue != 1 || info.IdlePct != 98 {
+		t.Fatalf("unexpected counters: %+v", info)
+	}
+	if info.Uptime != "0d 1h02m03s" || info.UptimeDuration() != time.Hour+2*time.Minute+3*time.Second {
+		t.Fatalf("unexpected uptime: %s %s", info.Uptime, info.UptimeDuration())
+	}
+	if info.Node != "lb01" || info.Stopping || info.UnstoppableJobs != 0 || info.Jobs != 17 {
+		t.Fatalf("unexpected state: %+v", info)
+	}
+	if info.TotalBytesOut != 44806716 || info.BuildInfo != "2.6.10-1ppa1~jammy" || info.BootTimeMs != 21 {
+		t.Fatalf("unexpected fields at the end: %+v", info)
+	}
+	if info.StartTime().Unix() != 1678440000 {
+		t.Fatalf("unexpected start time: %s", info.StartTime())
+	}
+	if info.Extra != nil {
+		t.Fatalf("expected all fields mapped got: %v", info.Extra)
+	}
+}
+
+func TestParseShowInfoExtra(t *testing.T) {
+	info, err := ParseShowInfo([]byte("Name: HAProxy\nQuicConns: 4\n"))
+	if err != nil {
+		t.Fatalf("unable to parse show info: %v", err)
+	}
+	if info.Extra["QuicConns"] != "4" {
+		t.Fatalf("expected unknown field in extra got: %v", info.Extra)
+	}
+}
+
+func TestParseShowInfoErrors(t *testing.T) {
+	var perr *ParseError
+
+	_, err := ParseShowInfo([]byte("Name: HAProxy\nPid: many\n"))
+	if !errors.As(err, &perr) || perr.Line != 2 || perr.Column != "Pid" || perr.Value != "many" {
+		t.Fatalf("expected parse error for Pid got: %v", err)
+	}
+
+	_, err = ParseShowInfo([]byte("Name: HAProxy\nbroken\n"))
+	if !errors.As(err, &perr) || perr.Line != 2 || perr.Column != "" {
+		t.Fatalf("expected parse error for the line got: %v", err)
+	}
+}
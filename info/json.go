@@ -0,0 +1,62 @@
+package info
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// a field of the show info json output, e.g.
+// {"field":{"pos":4,"name":"Uptime_sec"},"processNum":1,
+// "tags":{"origin":"Metric","nature":"Duration","scope":"Process"},"value":{"type":"u32","value":3600}}
+// Reference: http://docs.haproxy.org/2.6/management.html#9.3 (show info json)
+type jsonField struct {
+	Field struct {
+		Pos  int    `json:"pos"`
+		Name string `json:"name"`
+	} `json:"field"`
+	Value struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"value"`
+}
+
+// the error object written instead of the fields when the output could not be produced
+type jsonError struct {
+	ErrorStr string `json:"errorStr"`
+}
+
+// parse the response of the command show info json from the Runtime API. The fields are set
+// by name as with show info, a value which can not be parsed is returned as a *ParseError
+// with the position of the field in place of the line number
+func ParseShowInfoJSON(response []byte) (*Info, error) {
+	trimmed := bytes.TrimSpace(response)
+	// HA-Proxy writes an object with an error text instead of the array of fields when the output fails
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var e jsonError
+		if err := json.Unmarshal(trimmed, &e); err != nil {
+			return nil, &ParseError{Value: string(trimmed), Err: err}
+		}
+		return nil, fmt.Errorf("json output failed: %s", e.ErrorStr)
+	}
+	var fields []jsonField
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	info := &Info{}
+	for _, f := range fields {
+		// numbers are written as json numbers and kept as their text like in show info
+		raw := bytes.TrimSpace(f.Value.Value)
+		value := string(raw)
+		if bytes.HasPrefix(raw, []byte(`"`)) {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, &ParseError{Line: f.Field.Pos, Column: f.Field.Name, Value: value, Err: err}
+			}
+		}
+		if err := info.Set(f.Field.Name, value); err != nil {
+			return nil, &ParseError{Line: f.Field.Pos, Column: f.Field.Name, Value: value, Err: err}
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,41 @@
+package info
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseShowInfoJSON(t *testing.T) {
+	response := `[{"field":{"pos":0,"name":"Name"},"processNum":1,"tags":{"origin":"Product","nature":"Output","scope":"Service"},"value":{"type":"str","value":"HAProxy"}},
+{"field":{"pos":1,"name":"Version"},"processNum":1,"tags":{"origin":"Product","nature":"Output","scope":"Service"},"value":{"type":"str","value":"2.6.10"}},
+{"field":{"pos":8,"name":"Uptime_sec"},"processNum":1,"tags":{"origin":"Metric","nature":"Duration","scope":"Process"},"value":{"type":"u32","value":3600}},
+{"field":{"pos":90,"name":"QuicConns"},"processNum":1,"tags":{"origin":"Metric","nature":"Gauge","scope":"Process"},"value":{"type":"u32","value":4}}]`
+
+	info, err := ParseShowInfoJSON([]byte(response))
+	if err != nil {
+		t.Fatalf("unable to parse json info: %v", err)
+	}
+	if info.Name != "HAProxy" || info.Version != "2.6.10" || info.UptimeSec != 3600 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.Extra["QuicConns"] != "4" {
+		t.Fatalf("expected unknown field in extra got: %v", info.Extra)
+	}
+}
+
+func TestParseShowInfoJSONErrors(t *testing.T) {
+	_, err := ParseShowInfoJSON([]byte(`{"errorStr":"output buffer too short"}`))
+	if err == nil || err.Error() != "json output failed: output buffer too short" {
+		t.Fatalf("expected the error of the output got: %v", err)
+	}
+
+	var perr *ParseError
+	if _, err := ParseShowInfoJSON([]byte(`[{"field":{"pos":0`)); !errors.As(err, &perr) {
+		t.Fatalf("expected parse error for truncated output got: %v", err)
+	}
+
+	_, err = ParseShowInfoJSON([]byte(`[{"field":{"pos":6,"name":"Pid"},"value":{"type":"str","value":"many"}}]`))
+	if !errors.As(err, &perr) || perr.Column != "Pid" || perr.Value != "many" {
+		t.Fatalf("expected parse error for Pid got: %v", err)
+	}
+}
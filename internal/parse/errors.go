@@ -0,0 +1,37 @@
+// package with the errors shared by the parsers of the responses of the Runtime API
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error reports a value in a response which could not be parsed
+type Error struct {
+	Line   int    // line number in the response starting from 1
+	Column string // name of the column or field, empty for errors in the format of the line
+	Value  string // the value which could not be parsed
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d column %s: unable to parse %q: %v", e.Line, e.Column, e.Value, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors are the errors of the lines left out when parsing leniently
+type Errors []*Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,28 @@
+package parse
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	_, numErr := strconv.Atoi("many")
+	err := &Error{Line: 2, Column: "scur", Value: "many", Err: numErr}
+	if err.Error() != `line 2 column scur: unable to parse "many": strconv.Atoi: parsing "many": invalid syntax` {
+		t.Fatalf("unexpected message: %s", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("expected the error to wrap the cause")
+	}
+
+	line := &Error{Line: 3, Err: errors.New("expected 8 columns got 2")}
+	if line.Error() != "line 3: expected 8 columns got 2" {
+		t.Fatalf("unexpected message: %s", line)
+	}
+
+	errs := Errors{err, line}
+	if errs.Error() != err.Error()+"; "+line.Error() {
+		t.Fatalf("unexpected message: %s", errs)
+	}
+}
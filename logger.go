@@ -0,0 +1,19 @@
+package haproxy
+
+// Logger is the structured logger used by a RuntimeClient for tracing commands and
+// reporting the progress of the maintenance operations. The arguments are alternating
+// keys and values as in log/slog, and a *slog.Logger can be used as the Logger.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// the default Logger discarding everything
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...any) {}
+func (nopLogger) Info(msg string, args ...any)  {}
+func (nopLogger) Warn(msg string, args ...any)  {}
+func (nopLogger) Error(msg string, args ...any) {}
@@ -0,0 +1,160 @@
+package haproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/industria/haproxy-runtime-api-client/stat"
+	"github.com/industria/haproxy-runtime-api-client/state"
+)
+
+// Instance is a HA-Proxy instance of a MultiClient identified by name
+type Instance struct {
+	Name   string // name tagging the results of the instance, e.g. the host name
+	Client *RuntimeClient
+}
+
+// InstanceStat is the result of show stat from an instance
+type InstanceStat struct {
+	Instance string
+	Stats    []stat.StatCounters
+}
+
+// InstanceServersState is the result of show servers state from an instance
+type InstanceServersState struct {
+	Instance string
+	States   []state.ServerState
+}
+
+// InstanceError is the error of a command on an instance of a MultiClient
+type InstanceError struct {
+	Instance string
+	Err      error
+}
+
+func (e *InstanceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Instance, e.Err)
+}
+
+func (e *InstanceError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError reports the instances failing a command of a MultiClient
+// while the results of the other instances are returned with the error
+type PartialError []*InstanceError
+
+func (e PartialError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// MultiClient executes the commands on several HA-Proxy instances concurrently,
+// e.g. instances behind the same DNS name running the same configuration
+type MultiClient struct {
+	instances []Instance
+}
+
+// create a MultiClient for the instances, the names of the instances must be unique
+func NewMultiClient(instances ...Instance) (*MultiClient, error) {
+	if len(instances) == 0 {
+		return nil, errors.New("at least one instance is required")
+	}
+	names := make(map[string]bool, len(instances))
+	for _, in := range instances {
+		if in.Name == "" {
+			return nil, errors.New("instance name must not be empty")
+		}
+		if in.Client == nil {
+			return nil, fmt.Errorf("client of instance %s must not be nil", in.Name)
+		}
+		if names[in.Name] {
+			return nil, fmt.Errorf("instance name %s is not unique", in.Name)
+		}
+		names[in.Name] = true
+	}
+	return &MultiClient{instances: append([]Instance(nil), instances...)}, nil
+}
+
+// get the stat counters of all instances using show stat with the optional filter.
+// The results are in the order of the instances. Instances failing are left out
+// of the results and reported in a PartialError returned with the results
+func (mc *MultiClient) ShowStat(ctx context.Context, filter ...StatFilter) ([]InstanceStat, error) {
+	results := make([]InstanceStat, len(mc.instances))
+	ok, err := mc.each(func(i int, in Instance) error {
+		stats, err := in.Client.ShowStatContext(ctx, filter...)
+		results[i] = InstanceStat{Instance: in.Name, Stats: stats}
+		return err
+	})
+	var stats []InstanceStat
+	for i := range results {
+		if ok[i] {
+			stats = append(stats, results[i])
+		}
+	}
+	return stats, err
+}
+
+// get the stat counters of all instances aggregated with stat.Aggregate
+// instances failing are reported in a PartialError returned with the aggregate of the other instances
+func (mc *MultiClient) ShowStatAggregate(ctx context.Context, filter ...StatFilter) ([]stat.StatCounters, error) {
+	results, err := mc.ShowStat(ctx, filter...)
+	snapshots := make([][]stat.StatCounters, 0, len(results))
+	for _, r := range results {
+		snapshots = append(snapshots, r.Stats)
+	}
+	return stat.Aggregate(snapshots...), err
+}
+
+// get the server state of all instances using show servers state. The results are in the
+// order of the instances, and instances failing are reported in a PartialError
+func (mc *MultiClient) ShowServersState(ctx context.Context) ([]InstanceServersState, error) {
+	results := make([]InstanceServersState, len(mc.instances))
+	ok, err := mc.each(func(i int, in Instance) error {
+		states, err := in.Client.ShowServersStateContext(ctx)
+		results[i] = InstanceServersState{Instance: in.Name, States: states}
+		return err
+	})
+	var states []InstanceServersState
+	for i := range results {
+		if ok[i] {
+			states = append(states, results[i])
+		}
+	}
+	return states, err
+}
+
+// call fn concurrently for all instances reporting the instances succeeding
+// the error is a PartialError with the instances failing or nil
+func (mc *MultiClient) each(fn func(i int, in Instance) error) ([]bool, error) {
+	errs := make([]error, len(mc.instances))
+	var wg sync.WaitGroup
+	for i, in := range mc.instances {
+		wg.Add(1)
+		go func(i int, in Instance) {
+			defer wg.Done()
+			errs[i] = fn(i, in)
+		}(i, in)
+	}
+	wg.Wait()
+
+	ok := make([]bool, len(mc.instances))
+	var partial PartialError
+	for i, err := range errs {
+		if err != nil {
+			partial = append(partial, &InstanceError{Instance: mc.instances[i].Name, Err: err})
+			continue
+		}
+		ok[i] = true
+	}
+	if len(partial) > 0 {
+		return ok, partial
+	}
+	return ok, nil
+}
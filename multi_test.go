@@ -0,0 +1,91 @@
+package haproxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+)
+
+// the fake HA-Proxy instances lb01 and lb02 and the instance lb03 refusing connections
+func newFleet(t *testing.T) (*haproxytest.Server, *haproxytest.Server, *MultiClient) {
+	srv1, client1 := newFakeHAProxy(t)
+	srv2, client2 := newFakeHAProxy(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	l.Close()
+	unreachable, err := NewClient("tcp://" + l.Addr().String())
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	mc, err := NewMultiClient(
+		Instance{Name: "lb01", Client: client1},
+		Instance{Name: "lb02", Client: client2},
+		Instance{Name: "lb03", Client: unreachable},
+	)
+	if err != nil {
+		t.Fatalf("unable to create multi client: %v", err)
+	}
+	return srv1, srv2, mc
+}
+
+func TestMultiClientShowStat(t *testing.T) {
+	srv1, srv2, mc := newFleet(t)
+	srv1.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 2 })
+	srv2.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.Scur = 3 })
+
+	results, err := mc.ShowStat(context.Background())
+	var partial PartialError
+	if !errors.As(err, &partial) || len(partial) != 1 || partial[0].Instance != "lb03" {
+		t.Fatalf("expected lb03 failing got: %v", err)
+	}
+	if len(results) != 2 || results[0].Instance != "lb01" || results[1].Instance != "lb02" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].Stats[0].Scur != 2 || results[1].Stats[0].Scur != 3 {
+		t.Fatalf("results not tagged with the instance: %+v", results)
+	}
+
+	stats, err := mc.ShowStatAggregate(context.Background())
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected partial error got: %v", err)
+	}
+	if len(stats) != 3 || stats[0].SvName != "iws01" || stats[0].Scur != 5 || stats[2].Scur != 5 {
+		t.Fatalf("unexpected aggregate: %+v", stats)
+	}
+}
+
+func TestMultiClientShowServersState(t *testing.T) {
+	_, srv2, mc := newFleet(t)
+	srv2.UpdateServer("indexws", "iws02", func(s *haproxytest.BackendServer) { s.State = "maint" })
+
+	results, err := mc.ShowServersState(context.Background())
+	var partial PartialError
+	if !errors.As(err, &partial) || len(partial) != 1 || partial[0].Instance != "lb03" {
+		t.Fatalf("expected lb03 failing got: %v", err)
+	}
+	if len(results) != 2 || results[1].Instance != "lb02" || results[1].States[1].SrvAdminState != 0x01 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestNewMultiClientErrors(t *testing.T) {
+	_, client := newFakeHAProxy(t)
+	tests := [][]Instance{
+		nil,
+		{{Name: "", Client: client}},
+		{{Name: "lb01"}},
+		{{Name: "lb01", Client: client}, {Name: "lb01", Client: client}},
+	}
+	for i, instances := range tests {
+		if _, err := NewMultiClient(instances...); err == nil {
+			t.Fatalf("expected instances %d to fail", i)
+		}
+	}
+}
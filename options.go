@@ -0,0 +1,153 @@
+package haproxy
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Option configures a RuntimeClient created with NewClient
+type Option func(*RuntimeClient) error
+
+// RetryPolicy for connecting to the stats socket. Only failing connection attempts are
+// retried as a command failing after it has been sent could already have been executed
+type RetryPolicy struct {
+	Attempts int           // total number of connection attempts, 1 means no retries
+	Backoff  time.Duration // wait before the first retry, doubled for each following retry
+}
+
+// AccessLevel of the commands on the stats socket. The level of a socket can only be lowered
+// so a level above the level configured for the socket has no effect
+type AccessLevel string
+
+const (
+	AccessLevelAdmin    AccessLevel = "admin"    // commands are executed with the level of the socket
+	AccessLevelOperator AccessLevel = "operator" // read only commands and non-sensitive changes
+	AccessLevelUser     AccessLevel = "user"     // read only commands with sensitive data hidden
+)
+
+// StatFormat is the output format requested from show stat by ShowStat
+type StatFormat string
+
+const (
+	StatFormatCSV   StatFormat = "csv"   // show stat, columns matched by the CSV header
+	StatFormatTyped StatFormat = "typed" // show stat typed, one line per field
+	StatFormatJSON  StatFormat = "json"  // show stat json, fields with explicit types
+)
+
+// log commands and maintenance progress to the logger, nothing is logged by default
+func WithLogger(logger Logger) Option {
+	return func(rc *RuntimeClient) error {
+		if logger == nil {
+			return errors.New("logger must not be nil")
+		}
+		rc.logger = logger
+		return nil
+	}
+}
+
+// limit the time for connecting to the stats socket, no limit by default
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(rc *RuntimeClient) error {
+		if timeout <= 0 {
+			return fmt.Errorf("dial timeout must be positive: %s", timeout)
+		}
+		rc.dialTimeout = timeout
+		return nil
+	}
+}
+
+// limit the time for executing a command including connecting, no limit by default
+// a shorter deadline on the context of a command still applies
+func WithCommandTimeout(timeout time.Duration) Option {
+	return func(rc *RuntimeClient) error {
+		if timeout <= 0 {
+			return fmt.Errorf("command timeout must be positive: %s", timeout)
+		}
+		rc.commandTimeout = timeout
+		return nil
+	}
+}
+
+// time between the checks for the completion of draining a server in ServerMaintenance, 10ms by default
+func WithPollInterval(interval time.Duration) Option {
+	return func(rc *RuntimeClient) error {
+		if interval <= 0 {
+			return fmt.Errorf("poll interval must be positive: %s", interval)
+		}
+		rc.pollInterval = interval
+		return nil
+	}
+}
+
+// retry connecting to the stats socket according to the policy, no retries by default
+func WithRetry(policy RetryPolicy) Option {
+	return func(rc *RuntimeClient) error {
+		if policy.Attempts < 1 {
+			return fmt.Errorf("retry attempts must be at least 1: %d", policy.Attempts)
+		}
+		if policy.Backoff < 0 {
+			return fmt.Errorf("retry backoff must not be negative: %s", policy.Backoff)
+		}
+		rc.retry = policy
+		return nil
+	}
+}
+
+// lower the access level of the commands sent by the client
+func WithAccessLevel(level AccessLevel) Option {
+	return func(rc *RuntimeClient) error {
+		switch level {
+		case AccessLevelAdmin, AccessLevelOperator, AccessLevelUser:
+			rc.accessLevel = level
+			return nil
+		default:
+			return fmt.Errorf("unknown access level: %s", level)
+		}
+	}
+}
+
+// output format used by ShowStat, StatFormatCSV by default. The counters are the same
+// for all formats so the format can be changed without changing the code using them
+func WithStatFormat(format StatFormat) Option {
+	return func(rc *RuntimeClient) error {
+		switch format {
+		case StatFormatCSV, StatFormatTyped, StatFormatJSON:
+			rc.statFormat = format
+			return nil
+		default:
+			return fmt.Errorf("unknown stat format: %s", format)
+		}
+	}
+}
+
+// connect to the stats socket using the dialer, a nil dialer like a nil *net.Dialer is rejected
+func WithDialer(dialer Dialer) Option {
+	return func(rc *RuntimeClient) error {
+		if isNil(dialer) {
+			return errors.New("dialer must not be nil")
+		}
+		rc.dialer = dialer
+		return nil
+	}
+}
+
+// reports if v is nil or an interface holding a nil pointer, map, func or channel
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Func, reflect.Chan, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// execute the commands using a pool of interactive sessions, see EnablePool
+func WithPool(config PoolConfig) Option {
+	return func(rc *RuntimeClient) error {
+		return rc.EnablePool(config)
+	}
+}
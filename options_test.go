@@ -0,0 +1,162 @@
+package haproxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logger recording the messages and arguments logged
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *recordingLogger) record(level, msg string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) { l.record("DEBUG", msg, args...) }
+func (l *recordingLogger) Info(msg string, args ...any)  { l.record("INFO", msg, args...) }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.record("WARN", msg, args...) }
+func (l *recordingLogger) Error(msg string, args ...any) { l.record("ERROR", msg, args...) }
+
+func TestWithLogger(t *testing.T) {
+	uri, _ := promptListener(t)
+	logger := &recordingLogger{}
+	client, err := NewClient(uri, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	if _, err := client.Execute("show info"); err != nil {
+		t.Fatalf("unable to execute show info: %v", err)
+	}
+
+	if len(logger.entries) != 1 || logger.entries[0] != "DEBUG execute command [command show info]" {
+		t.Fatalf("unexpected log entries: %q", logger.entries)
+	}
+}
+
+func TestWithLoggerNil(t *testing.T) {
+	if _, err := NewClient("tcp://localhost:9999", WithLogger(nil)); err == nil {
+		t.Fatalf("expected nil logger to fail")
+	}
+}
+
+// listener answering every connection with the same response and recording the received commands
+func recordingListener(t *testing.T, response string) (string, func() []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var mu sync.Mutex
+	var commands []string
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			mu.Lock()
+			commands = append(commands, strings.TrimSpace(line))
+			mu.Unlock()
+			conn.Write([]byte(response))
+			conn.Close()
+		}
+	}()
+	return "tcp://" + l.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return commands
+	}
+}
+
+func TestNewClientErrors(t *testing.T) {
+	_, err := NewClient("http://localhost:9999")
+	if err == nil || !strings.Contains(err.Error(), "http://localhost:9999") {
+		t.Fatalf("expected error naming the uri got: %v", err)
+	}
+	if _, err := NewClient("unix://"); err == nil {
+		t.Fatalf("expected missing path to fail")
+	}
+}
+
+func TestOptionValidation(t *testing.T) {
+	options := []Option{
+		WithDialTimeout(0),
+		WithCommandTimeout(-time.Second),
+		WithPollInterval(0),
+		WithRetry(RetryPolicy{Attempts: 0}),
+		WithRetry(RetryPolicy{Attempts: 2, Backoff: -time.Second}),
+		WithAccessLevel("root"),
+		WithDialer(nil),
+		WithDialer((*net.Dialer)(nil)),
+		WithPool(PoolConfig{MaxOpen: -1}),
+		WithStatFormat("xml"),
+	}
+	for i, opt := range options {
+		if _, err := NewClient("tcp://localhost:9999", opt); err == nil {
+			t.Fatalf("expected option %d to fail", i)
+		}
+	}
+}
+
+func TestWithCommandTimeout(t *testing.T) {
+	client, err := NewClient(silentListener(t), WithCommandTimeout(time.Millisecond*50))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	if _, err := client.Execute("show info"); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded got: %v", err)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	// find a port nobody is listening on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	client, err := NewClient("tcp://"+address, WithRetry(RetryPolicy{Attempts: 3, Backoff: time.Millisecond * 20}))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := client.Execute("show info"); err == nil {
+		t.Fatalf("expected connection to fail")
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*60 {
+		t.Fatalf("expected two retries with backoff got %s", elapsed)
+	}
+}
+
+func TestWithAccessLevel(t *testing.T) {
+	uri, commands := recordingListener(t, "\n")
+	client, err := NewClient(uri, WithAccessLevel(AccessLevelOperator))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	if _, err := client.Execute("show info"); err != nil {
+		t.Fatalf("unable to execute show info: %v", err)
+	}
+	if c := commands(); len(c) != 1 || c[0] != "operator; show info" {
+		t.Fatalf("unexpected commands: %q", c)
+	}
+}
@@ -0,0 +1,223 @@
+package haproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// returned when executing commands on a RuntimeClient with a closed pool
+var ErrClientClosed = errors.New("client is closed")
+
+// PoolConfig configures the pool of interactive sessions used by a RuntimeClient
+// HA-Proxy closes idle stats socket connections after the "stats timeout" (10s by default)
+// so MaxIdleTime should be kept below that to avoid reusing connections closed by HA-Proxy
+type PoolConfig struct {
+	MaxIdle     int           // maximum number of idle sessions kept open between commands
+	MaxOpen     int           // maximum number of open sessions, 0 for no limit. Commands wait for a session when the limit is reached
+	MaxIdleTime time.Duration // idle sessions older than this are closed instead of reused, 0 for no limit
+	CheckIdle   time.Duration // idle sessions unused for longer than this are checked with an empty command before reuse, 0 for DefaultCheckIdle and negative for checking on every reuse
+}
+
+// DefaultCheckIdle is used when PoolConfig.CheckIdle is 0. Checking a session costs a round trip
+// before the command, so only sessions idle for a while are checked
+const DefaultCheckIdle = time.Second
+
+// an idle session and the time it was returned to the pool
+type idleSession struct {
+	sess  *Session
+	since time.Time
+}
+
+// pool of interactive sessions shared by the goroutines using a RuntimeClient
+type pool struct {
+	rc     *RuntimeClient
+	config PoolConfig
+	idle   chan idleSession // sessions ready for reuse
+	open   chan struct{}    // a token for every open session when MaxOpen is set, nil otherwise
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// use a pool of interactive sessions for all commands executed by the client
+// the pool must be enabled before the client is used, and the client should be
+// closed when it is no longer needed to close the idle sessions
+func (rc *RuntimeClient) EnablePool(config PoolConfig) error {
+	if config.MaxIdle < 0 {
+		return fmt.Errorf("pool max idle must not be negative: %d", config.MaxIdle)
+	}
+	if config.MaxOpen < 0 {
+		return fmt.Errorf("pool max open must not be negative: %d", config.MaxOpen)
+	}
+	if config.MaxOpen > 0 && config.MaxIdle > config.MaxOpen {
+		return fmt.Errorf("pool max idle %d must not exceed max open %d", config.MaxIdle, config.MaxOpen)
+	}
+	if config.MaxIdleTime < 0 {
+		return fmt.Errorf("pool max idle time must not be negative: %s", config.MaxIdleTime)
+	}
+	if config.CheckIdle == 0 {
+		config.CheckIdle = DefaultCheckIdle
+	}
+
+	p := &pool{
+		rc:     rc,
+		config: config,
+		idle:   make(chan idleSession, config.MaxIdle),
+	}
+	if config.MaxOpen > 0 {
+		p.open = make(chan struct{}, config.MaxOpen)
+	}
+	rc.pool = p
+	return nil
+}
+
+// close the idle sessions of the pool, sessions in use are closed when they are returned
+// closing a client without a pool does nothing
+func (rc *RuntimeClient) Close() error {
+	if rc.pool == nil {
+		return nil
+	}
+	return rc.pool.close()
+}
+
+// execute the command on a pooled session. A command failing because a reused session
+// was closed by HA-Proxy, as happens on reloads, is retried once on a new session
+func (p *pool) execute(ctx context.Context, command string) ([]byte, error) {
+	for retry := true; ; retry = false {
+		sess, reused, err := p.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := sess.ExecuteContext(ctx, command)
+		p.put(sess)
+
+		if err != nil && reused && retry && ctx.Err() == nil && closedByPeer(err) {
+			p.rc.logger.Debug("pooled session closed by haproxy - retrying on a new session", "command", commandLine(command), "error", err)
+			continue
+		}
+		return resp, err
+	}
+}
+
+// get an idle session or open a new session, reused reports if the session was idle in the pool
+func (p *pool) get(ctx context.Context) (sess *Session, reused bool, err error) {
+	for {
+		if p.isClosed() {
+			return nil, false, ErrClientClosed
+		}
+
+		var idle idleSession
+		select {
+		case idle = <-p.idle:
+		default:
+			if p.open == nil {
+				return p.dial(ctx)
+			}
+			// wait for either an idle session or for the number of open sessions to drop below MaxOpen
+			select {
+			case idle = <-p.idle:
+			case p.open <- struct{}{}:
+				return p.dial(ctx)
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+		}
+
+		if p.usable(ctx, idle) {
+			return idle.sess, true, nil
+		}
+		p.discard(idle.sess)
+	}
+}
+
+// open a new session, the open token must be held before dialing when MaxOpen is set
+func (p *pool) dial(ctx context.Context) (*Session, bool, error) {
+	sess, err := p.rc.OpenSession(ctx)
+	if err != nil {
+		p.release()
+		return nil, false, err
+	}
+	return sess, false, nil
+}
+
+// check that an idle session can be reused
+func (p *pool) usable(ctx context.Context, idle idleSession) bool {
+	age := time.Since(idle.since)
+	if p.config.MaxIdleTime > 0 && age > p.config.MaxIdleTime {
+		return false
+	}
+	if age < p.config.CheckIdle {
+		return true
+	}
+	// an empty command line in interactive mode is only answered by the prompt
+	_, err := idle.sess.ExecuteContext(ctx, "")
+	return err == nil
+}
+
+// return a session to the pool, broken sessions and sessions exceeding MaxIdle are closed
+func (p *pool) put(sess *Session) {
+	if sess.isClosed() || p.isClosed() {
+		p.discard(sess)
+		return
+	}
+
+	select {
+	case p.idle <- idleSession{sess: sess, since: time.Now()}:
+		// the pool could have been closed while the session was returned
+		if p.isClosed() {
+			p.drain()
+		}
+	default:
+		p.discard(sess)
+	}
+}
+
+// close a session and release its open token
+func (p *pool) discard(sess *Session) {
+	sess.Close()
+	p.release()
+}
+
+func (p *pool) release() {
+	if p.open != nil {
+		<-p.open
+	}
+}
+
+func (p *pool) close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.drain()
+	return nil
+}
+
+// close all idle sessions
+func (p *pool) drain() {
+	for {
+		select {
+		case idle := <-p.idle:
+			p.discard(idle.sess)
+		default:
+			return
+		}
+	}
+}
+
+func (p *pool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// errors indicating that the connection was closed by HA-Proxy before the command was answered
+func closedByPeer(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
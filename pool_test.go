@@ -0,0 +1,165 @@
+package haproxy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+)
+
+func TestPoolReusesSessions(t *testing.T) {
+	uri, accepted := promptListener(t)
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	if err := client.EnablePool(PoolConfig{MaxIdle: 2, MaxOpen: 2, CheckIdle: time.Minute}); err != nil {
+		t.Fatalf("unable to enable pool: %v", err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Execute("show info")
+			if err != nil {
+				t.Errorf("unable to execute show info: %v", err)
+				return
+			}
+			if string(resp) != "echo show info\n\n" {
+				t.Errorf("unexpected response: %q", resp)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted() > 2 {
+		t.Fatalf("expected at most 2 connections got %d", accepted())
+	}
+}
+
+func TestPoolRedialsClosedSessions(t *testing.T) {
+	// every connection is closed after a single command as if HA-Proxy was reloaded
+	uri, accepted := limitedPromptListener(t, 1)
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	if err := client.EnablePool(PoolConfig{MaxIdle: 1, CheckIdle: time.Minute}); err != nil {
+		t.Fatalf("unable to enable pool: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Execute("show info"); err != nil {
+			t.Fatalf("unable to execute show info: %v", err)
+		}
+	}
+
+	if accepted() != 3 {
+		t.Fatalf("expected 3 connections got %d", accepted())
+	}
+}
+
+func TestPoolChecksIdleSessions(t *testing.T) {
+	uri, accepted := limitedPromptListener(t, 1)
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	if err := client.EnablePool(PoolConfig{MaxIdle: 1, CheckIdle: -1}); err != nil {
+		t.Fatalf("unable to enable pool: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Execute("show info"); err != nil {
+			t.Fatalf("unable to execute show info: %v", err)
+		}
+	}
+
+	if accepted() != 3 {
+		t.Fatalf("expected 3 connections got %d", accepted())
+	}
+}
+
+func TestPoolWaitsForMaxOpen(t *testing.T) {
+	client, err := NewClient(silentListener(t))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	if err := client.EnablePool(PoolConfig{MaxOpen: 1}); err != nil {
+		t.Fatalf("unable to enable pool: %v", err)
+	}
+	defer client.Close()
+
+	// the only session is blocked opening on the silent listener until the test ends
+	blocked, unblock := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		unblock()
+		<-done
+	})
+	go func() {
+		defer close(done)
+		client.ExecuteContext(blocked, "show info")
+	}()
+	time.Sleep(time.Millisecond * 20)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if _, err := client.ExecuteContext(ctx, "show info"); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded got: %v", err)
+	}
+}
+
+// idle sessions are only checked after DefaultCheckIdle so a reuse costs a single round trip
+func TestPoolDefaultCheckIdle(t *testing.T) {
+	srv := haproxytest.NewServer()
+	defer srv.Close()
+
+	for _, test := range []struct {
+		checkIdle time.Duration
+		sent      string
+	}{
+		{0, "prompt\nshow info\nshow info\nshow info\nquit"},
+		{-1, "prompt\nshow info\n\nshow info\n\nshow info\nquit"},
+	} {
+		recorder := haproxytest.NewRecorder(nil)
+		client, err := NewClient(srv.URI, WithDialer(recorder))
+		if err != nil {
+			t.Fatalf("unable to create client: %v", err)
+		}
+		if err := client.EnablePool(PoolConfig{MaxIdle: 1, CheckIdle: test.checkIdle}); err != nil {
+			t.Fatalf("unable to enable pool: %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := client.Execute("show info"); err != nil {
+				t.Fatalf("unable to execute show info: %v", err)
+			}
+		}
+		client.Close()
+
+		exchanges := recorder.Transcript().Exchanges
+		if len(exchanges) != 1 || exchanges[0].Command != test.sent {
+			t.Fatalf("check idle %s: unexpected exchanges: %+v", test.checkIdle, exchanges)
+		}
+	}
+}
+
+func TestPoolConfigValidation(t *testing.T) {
+	client, err := NewClient("tcp://localhost:9999")
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	if err := client.EnablePool(PoolConfig{MaxIdle: 3, MaxOpen: 2}); err == nil {
+		t.Fatalf("expected max idle above max open to fail")
+	}
+	if err := client.EnablePool(PoolConfig{MaxOpen: -1}); err == nil {
+		t.Fatalf("expected negative max open to fail")
+	}
+}
@@ -0,0 +1,199 @@
+package haproxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the prompt written by the Runtime API in interactive mode when it is ready for the next command
+var prompt = []byte("> ")
+
+// returned when using a Session that has been closed or broken by a failed command
+var ErrSessionClosed = errors.New("session is closed")
+
+// Session is a persistent connection to the Runtime API using the interactive mode
+// enabled with the prompt command. The connection is kept open between commands
+// and responses are framed by the prompt written after each response.
+// A Session is safe for concurrent use, commands from multiple goroutines are
+// sent one exchange at a time in the order they acquire the session.
+type Session struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	logger  Logger
+	timeout time.Duration // command timeout of the client
+	closed  bool
+}
+
+// open a Session on the stats socket and switch the connection to interactive mode
+// the context is only used while opening the session
+func (rc *RuntimeClient) OpenSession(ctx context.Context) (*Session, error) {
+	conn, err := rc.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		logger:  rc.logger,
+		timeout: rc.commandTimeout,
+	}
+
+	// the response to prompt is only the prompt itself
+	if _, err := sess.ExecuteContext(ctx, "prompt"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// the access level is lowered once for the lifetime of the session
+	if rc.accessLevel != AccessLevelAdmin {
+		if _, err := sess.ExecuteContext(ctx, string(rc.accessLevel)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return sess, nil
+}
+
+// execute a HA-Proxy runtime api command on the session
+// result is the raw response from the API without the prompt
+func (s *Session) Execute(command string) ([]byte, error) {
+	return s.ExecuteContext(context.Background(), command)
+}
+
+// execute a HA-Proxy runtime api command on the session bound to the context
+// if the context ends while the command is in progress the session is closed, as the
+// remaining response would otherwise be read as the response of the next command
+func (s *Session) ExecuteContext(ctx context.Context, command string) ([]byte, error) {
+	responses, err := s.Pipeline(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	return responses[0], nil
+}
+
+// execute several commands as one semicolon separated command line
+// result is the combined raw response of the commands as HA-Proxy only writes
+// a prompt after the complete command line has been processed
+func (s *Session) ExecuteBatch(ctx context.Context, commands ...string) ([]byte, error) {
+	return s.ExecuteContext(ctx, strings.Join(commands, "; "))
+}
+
+// send all commands before reading any of the responses, saving a round trip per command
+// result contains the raw response for each command in the order of the commands
+func (s *Session) Pipeline(ctx context.Context, commands ...string) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrSessionClosed
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	stop := watchContext(ctx, s.conn)
+	responses, err := s.roundTrip(commands)
+	// stop watching the context before clearing the deadline, so a context ending in between
+	// can not leave a deadline in the past on the open session failing the next command
+	stop()
+	if err != nil {
+		s.close()
+		return nil, contextError(ctx, err)
+	}
+
+	// clear the deadline from the context so it does not apply to the next command
+	s.conn.SetDeadline(time.Time{})
+
+	return responses, nil
+}
+
+// send all commands and read the responses
+func (s *Session) roundTrip(commands []string) ([][]byte, error) {
+	var cmd bytes.Buffer
+	for _, command := range commands {
+		s.logger.Debug("execute command", "command", commandLine(command), "session", true)
+		cmd.WriteString(command)
+		cmd.WriteByte('\n')
+	}
+
+	if _, err := s.conn.Write(cmd.Bytes()); err != nil {
+		return nil, fmt.Errorf("unable to send command: %w", err)
+	}
+
+	responses := make([][]byte, 0, len(commands))
+	for range commands {
+		resp, err := s.readResponse()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read response: %w", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// read a response terminated by the prompt, the prompt is always written at the start of a line
+// io.EOF is only returned when the connection was closed before any part of the response was read
+func (s *Session) readResponse() ([]byte, error) {
+	var resp []byte
+	for {
+		start, err := s.reader.Peek(len(prompt))
+		if err != nil {
+			return nil, unexpectedEOF(err, len(resp)+len(start))
+		}
+		if bytes.Equal(start, prompt) {
+			s.reader.Discard(len(prompt))
+			return resp, nil
+		}
+
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			return nil, unexpectedEOF(err, len(resp)+len(line))
+		}
+		resp = append(resp, line...)
+	}
+}
+
+// io.EOF after a partial response is unexpected
+func unexpectedEOF(err error, read int) error {
+	if err == io.EOF && read > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// close the session leaving interactive mode with the quit command
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.conn.Write([]byte("quit\n"))
+	return s.close()
+}
+
+func (s *Session) close() error {
+	s.closed = true
+	return s.conn.Close()
+}
+
+func (s *Session) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
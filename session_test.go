@@ -0,0 +1,214 @@
+package haproxy
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// listener answering commands in interactive mode with the command echoed back
+// the number of accepted connections is reported through the returned function
+func promptListener(t *testing.T) (string, func() int) {
+	return limitedPromptListener(t, 0)
+}
+
+// listener answering commands in interactive mode closing the connection after limit commands
+// a limit of 0 keeps the connection open, the prompt command is not counted
+func limitedPromptListener(t *testing.T, limit int) (string, func() int) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var mu sync.Mutex
+	accepted := 0
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepted++
+			mu.Unlock()
+			go servePrompt(conn, limit)
+		}
+	}()
+	return "tcp://" + l.Addr().String(), func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return accepted
+	}
+}
+
+func servePrompt(conn net.Conn, limit int) {
+	defer conn.Close()
+	interactive := false
+	served := 0
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if limit > 0 && served == limit {
+			return
+		}
+		if scanner.Text() != "prompt" {
+			served++
+		}
+		var out strings.Builder
+		for _, cmd := range strings.Split(scanner.Text(), ";") {
+			cmd = strings.TrimSpace(cmd)
+			switch {
+			case cmd == "prompt":
+				interactive = true
+			case cmd == "quit":
+				return
+			case cmd != "":
+				out.WriteString("echo " + cmd + "\n")
+			}
+		}
+		if interactive {
+			out.WriteString("\n> ")
+		} else {
+			out.WriteString("\n")
+		}
+		if _, err := conn.Write([]byte(out.String())); err != nil || !interactive {
+			return
+		}
+	}
+}
+
+func TestSessionExecute(t *testing.T) {
+	uri, accepted := promptListener(t)
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	sess, err := client.OpenSession(context.Background())
+	if err != nil {
+		t.Fatalf("unable to open session: %v", err)
+	}
+	defer sess.Close()
+
+	for i := 0; i < 10; i++ {
+		resp, err := sess.Execute("show info")
+		if err != nil {
+			t.Fatalf("unable to execute show info: %v", err)
+		}
+		if string(resp) != "echo show info\n\n" {
+			t.Fatalf("unexpected response: %q", resp)
+		}
+	}
+
+	if accepted() != 1 {
+		t.Fatalf("expected a single connection got %d", accepted())
+	}
+}
+
+func TestSessionBatchAndPipeline(t *testing.T) {
+	uri, _ := promptListener(t)
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	sess, err := client.OpenSession(context.Background())
+	if err != nil {
+		t.Fatalf("unable to open session: %v", err)
+	}
+	defer sess.Close()
+
+	resp, err := sess.ExecuteBatch(context.Background(), "show info", "show stat")
+	if err != nil {
+		t.Fatalf("unable to execute batch: %v", err)
+	}
+	if string(resp) != "echo show info\necho show stat\n\n" {
+		t.Fatalf("unexpected batch response: %q", resp)
+	}
+
+	responses, err := sess.Pipeline(context.Background(), "show info", "show stat")
+	if err != nil {
+		t.Fatalf("unable to execute pipeline: %v", err)
+	}
+	if len(responses) != 2 || string(responses[0]) != "echo show info\n\n" || string(responses[1]) != "echo show stat\n\n" {
+		t.Fatalf("unexpected pipeline responses: %q", responses)
+	}
+}
+
+func TestSessionConcurrent(t *testing.T) {
+	uri, _ := promptListener(t)
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	sess, err := client.OpenSession(context.Background())
+	if err != nil {
+		t.Fatalf("unable to open session: %v", err)
+	}
+	defer sess.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			command := "show " + strings.Repeat("x", i+1)
+			resp, err := sess.Execute(command)
+			if err != nil {
+				t.Errorf("unable to execute %s: %v", command, err)
+				return
+			}
+			if string(resp) != "echo "+command+"\n\n" {
+				t.Errorf("unexpected response for %s: %q", command, resp)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestOpenSessionDeadline(t *testing.T) {
+	client, err := NewClient(silentListener(t))
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	if _, err := client.OpenSession(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded got: %v", err)
+	}
+}
+
+func TestSessionUsableAfterCancel(t *testing.T) {
+	uri, _ := promptListener(t)
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	sess, err := client.OpenSession(context.Background())
+	if err != nil {
+		t.Fatalf("unable to open session: %v", err)
+	}
+	defer sess.Close()
+
+	// contexts ending right after the commands must not leave a deadline on the session
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		go cancel()
+		if _, err := sess.ExecuteContext(ctx, "show info"); err != nil {
+			if err != context.Canceled {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return
+		}
+		if _, err := sess.Execute("show info"); err != nil {
+			t.Fatalf("session not usable after cancel: %v", err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package haproxy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/industria/haproxy-runtime-api-client/state"
+)
+
+// turn ssl for the connections to a server on or off using set server <backend>/<server> ssl on|off.
+// The ssl settings must be defined on the server or the default-server of the backend, and
+// HA-Proxy closes the idle connections to the server. The change is verified with show servers state
+func (rc *RuntimeClient) SetServerSSL(backend, server string, enabled bool) error {
+	return rc.SetServerSSLContext(context.Background(), backend, server, enabled)
+}
+
+// turn ssl for the connections to a server on or off bound to the context
+func (rc *RuntimeClient) SetServerSSLContext(ctx context.Context, backend, server string, enabled bool) error {
+	return setServerSSL(ctx, rc, backend, server, enabled)
+}
+
+func setServerSSL(ctx context.Context, ex executor, backend, server string, enabled bool) error {
+	mode := "off"
+	if enabled {
+		mode = "on"
+	}
+	command := fmt.Sprintf("set server %s/%s ssl %s", backend, server, mode)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	if err := expectResponse(command, resp, "", "server ssl setting updated."); err != nil {
+		return err
+	}
+
+	s, err := serverState(ctx, ex, backend, server)
+	if err != nil {
+		return err
+	}
+	if s.SrvUseSSL != enabled {
+		return &CommandError{
+			Command:  command,
+			Response: fmt.Sprintf("server state has srv_use_ssl %t", s.SrvUseSSL),
+			Err:      ErrNotApplied,
+		}
+	}
+	return nil
+}
+
+// switch ssl for the connections to a server on or off, draining the sessions first. The server is
+// placed into maintenance with ServerMaintenance draining the sessions until the context ends, when
+// the remaining sessions are cut by forcing the server into maintenance. After switching ssl the
+// server is set back to the state it had before, also when switching fails, and the error is returned.
+// A server already in maintenance is not drained and stays in maintenance
+func (rc *RuntimeClient) RotateServerSSL(ctx context.Context, backend, server string, enabled bool) error {
+	return rc.withServerDrained(ctx, backend, server, func() error {
+		rc.logger.Info("switching server ssl", "backend", backend, "server", server, "ssl", enabled)
+		return rc.SetServerSSL(backend, server, enabled)
+	})
+}
+
+// update a certificate of the servers with a new certificate in PEM format, draining the sessions of
+// a server using it first. The server is drained and restored as with RotateServerSSL and the
+// certificate is replaced with UpdateSSLCert while the server is in maintenance
+func (rc *RuntimeClient) RotateServerCert(ctx context.Context, backend, server, file string, pem []byte) error {
+	return rc.withServerDrained(ctx, backend, server, func() error {
+		rc.logger.Info("updating server certificate", "backend", backend, "server", server, "file", file)
+		return rc.UpdateSSLCert(file, pem)
+	})
+}
+
+// run fn with the server in maintenance and set the server back to the admin state it had before,
+// also when placing the server into maintenance fails
+func (rc *RuntimeClient) withServerDrained(ctx context.Context, backend, server string, fn func() error) error {
+	s, err := serverState(ctx, rc, backend, server)
+	if err != nil {
+		return err
+	}
+	previous := ServerStateReady
+	switch {
+	case s.SrvAdminState&state.AdminStateForcedMaintenance != 0:
+		previous = ServerStateMaint
+	case s.SrvAdminState&state.AdminStateForcedDrain != 0:
+		previous = ServerStateDrain
+	}
+
+	if previous != ServerStateMaint {
+		if err := rc.ServerMaintenance(ctx, backend, server); err != nil {
+			// the drain can already be set when the draining fails, so the server is set back
+			rc.logger.Warn("draining server failed - restoring server state", "backend", backend, "server", server, "state", previous, "error", err)
+			if restoreErr := rc.SetServerState(backend, server, previous); restoreErr != nil {
+				rc.logger.Warn("restoring server state failed", "backend", backend, "server", server, "error", restoreErr)
+			}
+			return err
+		}
+	}
+
+	// the context bounds the draining so the change and restoring the state
+	// are only bound by the command timeout
+	fnErr := fn()
+	if fnErr != nil {
+		rc.logger.Warn("changing server in maintenance failed", "backend", backend, "server", server, "error", fnErr)
+	}
+	if previous != ServerStateMaint {
+		rc.logger.Info("restoring server state", "backend", backend, "server", server, "state", previous)
+		if err := rc.SetServerState(backend, server, previous); err != nil {
+			return err
+		}
+	}
+	return fnErr
+}
+
+// replace a certificate used by the configuration with a new certificate in PEM format using a transaction
+// of set ssl cert <file> followed by commit ssl cert <file>. The PEM can hold the certificate, the key and
+// the chain as the certificate file does. The transaction is aborted when the commit fails. The new
+// certificate is used for new connections while established connections keep the old one
+func (rc *RuntimeClient) UpdateSSLCert(file string, pem []byte) error {
+	return rc.UpdateSSLCertContext(context.Background(), file, pem)
+}
+
+// replace a certificate bound to the context
+func (rc *RuntimeClient) UpdateSSLCertContext(ctx context.Context, file string, pem []byte) error {
+	return updateSSLCert(ctx, rc, file, pem)
+}
+
+func updateSSLCert(ctx context.Context, ex executor, file string, pem []byte) error {
+	if file == "" || strings.ContainsAny(file, " \t\r\n;") {
+		return fmt.Errorf("invalid certificate file: %q", file)
+	}
+	// the payload ends at the first empty line so empty lines of the PEM are left out
+	var lines []string
+	for _, line := range strings.Split(string(pem), "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("empty certificate for %s", file)
+	}
+
+	// the payload is left out of errors and logs as it can contain the private key
+	command := fmt.Sprintf("set ssl cert %s", file)
+	resp, err := ex.ExecuteContext(ctx, command+" <<\n"+strings.Join(lines, "\n")+"\n")
+	if err != nil {
+		return err
+	}
+	if err := responseError(command, resp); err != nil {
+		return err
+	}
+	text := strings.TrimSpace(string(resp))
+	if !strings.HasPrefix(text, "Transaction created for certificate") && !strings.HasPrefix(text, "Transaction updated for certificate") {
+		return &CommandError{Command: command, Response: text, Err: ErrUnexpectedResponse}
+	}
+
+	command = fmt.Sprintf("commit ssl cert %s", file)
+	resp, err = ex.ExecuteContext(ctx, command)
+	if err == nil {
+		err = responseError(command, resp)
+	}
+	if err == nil && !hasLine(resp, "Success!") {
+		err = &CommandError{Command: command, Response: strings.TrimSpace(string(resp)), Err: ErrNotApplied}
+	}
+	if err != nil {
+		// the transaction is aborted so the next update starts from the certificate in use
+		abort := fmt.Sprintf("abort ssl cert %s", file)
+		if _, abortErr := ex.ExecuteContext(ctx, abort); abortErr != nil {
+			return fmt.Errorf("%w (%s failed: %v)", err, abort, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// check if a line of the response is the text with surrounding white space removed
+func hasLine(resp []byte, text string) bool {
+	for _, line := range strings.Split(string(resp), "\n") {
+		if strings.TrimSpace(line) == text {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package stat
+
+// aggregate the stats of the same objects in several snapshots, e.g. from several
+// HA-Proxy instances with the same configuration. The objects are matched by Key and
+// kept in the order they first appear. The sessions, queues, rates and cumulative counters
+// are summed, the maximums and the average times are the maximum of the snapshots, and the
+// status, configuration and the other values are taken from the first snapshot of the object
+func Aggregate(snapshots ...[]StatCounters) []StatCounters {
+	var stats []StatCounters
+	index := make(map[Key]int)
+	for _, snapshot := range snapshots {
+		for i := range snapshot {
+			c := &snapshot[i]
+			key := KeyOf(c)
+			j, ok := index[key]
+			if !ok {
+				index[key] = len(stats)
+				stats = append(stats, *c)
+				continue
+			}
+			add(&stats[j], c)
+		}
+	}
+	return stats
+}
+
+// add the values of src to dst
+func add(dst, src *StatCounters) {
+	dst.Qcur += src.Qcur
+	dst.Scur += src.Scur
+	dst.Stot += src.Stot
+	dst.Bin += src.Bin
+	dst.Bout += src.Bout
+	dst.Dreg += src.Dreg
+	dst.Dresp += src.Dresp
+	dst.Ereg += src.Ereg
+	dst.Econ += src.Econ
+	dst.Eresp += src.Eresp
+	dst.Wretr += src.Wretr
+	dst.Wredis += src.Wredis
+	dst.ChkFail += src.ChkFail
+	dst.ChkDown += src.ChkDown
+	dst.Lbtot += src.Lbtot
+	dst.Rate += src.Rate
+	dst.Hrsp1xx += src.Hrsp1xx
+	dst.Hrsp2xx += src.Hrsp2xx
+	dst.Hrsp3xx += src.Hrsp3xx
+	dst.Hrsp4xx += src.Hrsp4xx
+	dst.Hrsp5xx += src.Hrsp5xx
+	dst.HrspOther += src.HrspOther
+	dst.HanaFail += src.HanaFail
+	dst.ReqRate += src.ReqRate
+	dst.ReqTot += src.ReqTot
+	dst.CliAbrt += src.CliAbrt
+	dst.SrvAbrt += src.SrvAbrt
+	dst.CompIn += src.CompIn
+	dst.CompOut += src.CompOut
+	dst.CompByp += src.CompByp
+	dst.CompRsp += src.CompRsp
+	dst.ConnRate += src.ConnRate
+	dst.ConnTot += src.ConnTot
+	dst.Intercepted += src.Intercepted
+	dst.Dcon += src.Dcon
+	dst.Dses += src.Dses
+	dst.Wrew += src.Wrew
+	dst.Connect += src.Connect
+	dst.Reuse += src.Reuse
+	dst.CacheLookups += src.CacheLookups
+	dst.CacheHits += src.CacheHits
+	dst.SrvIcur += src.SrvIcur
+	dst.Eint += src.Eint
+	dst.IdleConnCur += src.IdleConnCur
+	dst.SafeConnCur += src.SafeConnCur
+	dst.UsedConnCur += src.UsedConnCur
+	dst.NeedConnEst += src.NeedConnEst
+
+	dst.SSL.Sess += src.SSL.Sess
+	dst.SSL.ReusedSess += src.SSL.ReusedSess
+	dst.SSL.FailedHandshake += src.SSL.FailedHandshake
+
+	dst.H2.HeadersRcvd += src.H2.HeadersRcvd
+	dst.H2.DataRcvd += src.H2.DataRcvd
+	dst.H2.SettingsRcvd += src.H2.SettingsRcvd
+	dst.H2.RstStreamRcvd += src.H2.RstStreamRcvd
+	dst.H2.GoawayRcvd += src.H2.GoawayRcvd
+	dst.H2.DetectedConnProtocolErrors += src.H2.DetectedConnProtocolErrors
+	dst.H2.DetectedStrmProtocolErrors += src.H2.DetectedStrmProtocolErrors
+	dst.H2.RstStreamResp += src.H2.RstStreamResp
+	dst.H2.GoawayResp += src.H2.GoawayResp
+	dst.H2.OpenConnections += src.H2.OpenConnections
+	dst.H2.BackendOpenStreams += src.H2.BackendOpenStreams
+	dst.H2.TotalConnections += src.H2.TotalConnections
+	dst.H2.BackendTotalStreams += src.H2.BackendTotalStreams
+
+	dst.H1.OpenConnections += src.H1.OpenConnections
+	dst.H1.OpenStreams += src.H1.OpenStreams
+	dst.H1.TotalConnections += src.H1.TotalConnections
+	dst.H1.TotalStreams += src.H1.TotalStreams
+	dst.H1.BytesIn += src.H1.BytesIn
+	dst.H1.BytesOut += src.H1.BytesOut
+	dst.H1.SplicedBytesIn += src.H1.SplicedBytesIn
+	dst.H1.SplicedBytesOut += src.H1.SplicedBytesOut
+
+	dst.Qmax = maxU32(dst.Qmax, src.Qmax)
+	dst.Smax = maxU32(dst.Smax, src.Smax)
+	dst.RateMax = maxU32(dst.RateMax, src.RateMax)
+	dst.ReqRateMax = maxU32(dst.ReqRateMax, src.ReqRateMax)
+	dst.ConnRateMax = maxU32(dst.ConnRateMax, src.ConnRateMax)
+	dst.Downtime = maxU32(dst.Downtime, src.Downtime)
+	dst.Qtime = maxU32(dst.Qtime, src.Qtime)
+	dst.Ctime = maxU32(dst.Ctime, src.Ctime)
+	dst.Rtime = maxU32(dst.Rtime, src.Rtime)
+	dst.Ttime = maxU32(dst.Ttime, src.Ttime)
+	dst.QtimeMax = maxU32(dst.QtimeMax, src.QtimeMax)
+	dst.CtimeMax = maxU32(dst.CtimeMax, src.CtimeMax)
+	dst.RtimeMax = maxU32(dst.RtimeMax, src.RtimeMax)
+	dst.TtimeMax = maxU32(dst.TtimeMax, src.TtimeMax)
+}
+
+func maxU32(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,39 @@
+package stat
+
+import "testing"
+
+func TestAggregate(t *testing.T) {
+	lb01 := []StatCounters{
+		{PxName: "indexws", SvName: "iws01", Type: ObjectTypeServer, Status: "UP", Scur: 2, Stot: 100, Hrsp5xx: 1, QtimeMax: 40, SSL: SSLCounters{Sess: 3}},
+		{PxName: "indexws", SvName: "BACKEND", Type: ObjectTypeBackend, Scur: 2, Stot: 100},
+	}
+	lb02 := []StatCounters{
+		{PxName: "indexws", SvName: "iws01", Type: ObjectTypeServer, Status: "DRAIN", Scur: 5, Stot: 50, Hrsp5xx: 4, QtimeMax: 25, SSL: SSLCounters{Sess: 2}},
+		{PxName: "indexws", SvName: "iws02", Type: ObjectTypeServer, Scur: 1, Stot: 10},
+		{PxName: "indexws", SvName: "BACKEND", Type: ObjectTypeBackend, Scur: 6, Stot: 60},
+	}
+
+	stats := Aggregate(lb01, lb02)
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 objects got %d", len(stats))
+	}
+
+	iws01 := stats[0]
+	if iws01.Scur != 7 || iws01.Stot != 150 || iws01.Hrsp5xx != 5 || iws01.SSL.Sess != 5 {
+		t.Fatalf("unexpected sums: %+v", iws01)
+	}
+	if iws01.QtimeMax != 40 || iws01.Status != "UP" {
+		t.Fatalf("unexpected maximum or status: %+v", iws01)
+	}
+	if stats[1].SvName != "BACKEND" || stats[1].Scur != 8 || stats[1].Stot != 160 {
+		t.Fatalf("unexpected backend: %+v", stats[1])
+	}
+	if stats[2].SvName != "iws02" || stats[2].Scur != 1 {
+		t.Fatalf("unexpected server only in one snapshot: %+v", stats[2])
+	}
+
+	// the snapshots are not changed
+	if lb01[0].Scur != 2 {
+		t.Fatalf("snapshot changed by aggregation")
+	}
+}
@@ -0,0 +1,166 @@
+package stat
+
+import (
+	"time"
+)
+
+// Key identifies the row of an object between snapshots of show stat. The ids of proxies and
+// servers can change when HA-Proxy is reloaded with a new configuration so the names are used
+type Key struct {
+	PxName string
+	SvName string
+	Type   ObjectType
+}
+
+// the key of the row
+func KeyOf(c *StatCounters) Key {
+	return Key{PxName: c.PxName, SvName: c.SvName, Type: c.Type}
+}
+
+// Delta is the change of the cumulative counters of an object between two snapshots
+type Delta struct {
+	Key      Key
+	Elapsed  time.Duration     // time between the snapshots
+	Reset    bool              // the counters were reset between the snapshots and are counted from zero
+	Counters map[string]uint64 // increase of the cumulative counters by the name of the show stat column
+	Current  StatCounters      // the object in the current snapshot for the gauges like scur
+}
+
+// the increase of the counter per second, 0 when the time between the snapshots is unknown
+func (d *Delta) Rate(column string) float64 {
+	if d.Elapsed <= 0 {
+		return 0
+	}
+	return float64(d.Counters[column]) / d.Elapsed.Seconds()
+}
+
+// SnapshotDiff is the difference between two snapshots of show stat
+type SnapshotDiff struct {
+	Deltas      []Delta        // objects in both snapshots in the order of the current snapshot
+	Appeared    []StatCounters // objects only in the current snapshot, e.g. servers added
+	Disappeared []StatCounters // objects only in the previous snapshot, e.g. servers deleted
+}
+
+// the cumulative counters of show stat by column name. Counters are only reset by
+// clear counters or when HA-Proxy is restarted or reloaded
+var cumulative = map[string]func(c *StatCounters) uint64{
+	"stot":                             func(c *StatCounters) uint64 { return c.Stot },
+	"bin":                              func(c *StatCounters) uint64 { return c.Bin },
+	"bout":                             func(c *StatCounters) uint64 { return c.Bout },
+	"dreq":                             func(c *StatCounters) uint64 { return c.Dreg },
+	"dresp":                            func(c *StatCounters) uint64 { return c.Dresp },
+	"ereq":                             func(c *StatCounters) uint64 { return c.Ereg },
+	"econ":                             func(c *StatCounters) uint64 { return c.Econ },
+	"eresp":                            func(c *StatCounters) uint64 { return c.Eresp },
+	"wretr":                            func(c *StatCounters) uint64 { return c.Wretr },
+	"wredis":                           func(c *StatCounters) uint64 { return c.Wredis },
+	"chkfail":                          func(c *StatCounters) uint64 { return c.ChkFail },
+	"chkdown":                          func(c *StatCounters) uint64 { return c.ChkDown },
+	"downtime":                         func(c *StatCounters) uint64 { return uint64(c.Downtime) },
+	"lbtot":                            func(c *StatCounters) uint64 { return c.Lbtot },
+	"hrsp_1xx":                         func(c *StatCounters) uint64 { return c.Hrsp1xx },
+	"hrsp_2xx":                         func(c *StatCounters) uint64 { return c.Hrsp2xx },
+	"hrsp_3xx":                         func(c *StatCounters) uint64 { return c.Hrsp3xx },
+	"hrsp_4xx":                         func(c *StatCounters) uint64 { return c.Hrsp4xx },
+	"hrsp_5xx":                         func(c *StatCounters) uint64 { return c.Hrsp5xx },
+	"hrsp_other":                       func(c *StatCounters) uint64 { return c.HrspOther },
+	"hanafail":                         func(c *StatCounters) uint64 { return c.HanaFail },
+	"req_tot":                          func(c *StatCounters) uint64 { return c.ReqTot },
+	"cli_abrt":                         func(c *StatCounters) uint64 { return c.CliAbrt },
+	"srv_abrt":                         func(c *StatCounters) uint64 { return c.SrvAbrt },
+	"comp_in":                          func(c *StatCounters) uint64 { return c.CompIn },
+	"comp_out":                         func(c *StatCounters) uint64 { return c.CompOut },
+	"comp_byp":                         func(c *StatCounters) uint64 { return c.CompByp },
+	"comp_rsp":                         func(c *StatCounters) uint64 { return c.CompRsp },
+	"conn_tot":                         func(c *StatCounters) uint64 { return c.ConnTot },
+	"intercepted":                      func(c *StatCounters) uint64 { return c.Intercepted },
+	"dcon":                             func(c *StatCounters) uint64 { return c.Dcon },
+	"dses":                             func(c *StatCounters) uint64 { return c.Dses },
+	"wrew":                             func(c *StatCounters) uint64 { return c.Wrew },
+	"connect":                          func(c *StatCounters) uint64 { return c.Connect },
+	"reuse":                            func(c *StatCounters) uint64 { return c.Reuse },
+	"cache_lookups":                    func(c *StatCounters) uint64 { return c.CacheLookups },
+	"cache_hits":                       func(c *StatCounters) uint64 { return c.CacheHits },
+	"eint":                             func(c *StatCounters) uint64 { return c.Eint },
+	"ssl_sess":                         func(c *StatCounters) uint64 { return c.SSL.Sess },
+	"ssl_reused_sess":                  func(c *StatCounters) uint64 { return c.SSL.ReusedSess },
+	"ssl_failed_handshake":             func(c *StatCounters) uint64 { return c.SSL.FailedHandshake },
+	"h2_headers_rcvd":                  func(c *StatCounters) uint64 { return c.H2.HeadersRcvd },
+	"h2_data_rcvd":                     func(c *StatCounters) uint64 { return c.H2.DataRcvd },
+	"h2_settings_rcvd":                 func(c *StatCounters) uint64 { return c.H2.SettingsRcvd },
+	"h2_rst_stream_rcvd":               func(c *StatCounters) uint64 { return c.H2.RstStreamRcvd },
+	"h2_goaway_rcvd":                   func(c *StatCounters) uint64 { return c.H2.GoawayRcvd },
+	"h2_detected_conn_protocol_errors": func(c *StatCounters) uint64 { return c.H2.DetectedConnProtocolErrors },
+	"h2_detected_strm_protocol_errors": func(c *StatCounters) uint64 { return c.H2.DetectedStrmProtocolErrors },
+	"h2_rst_stream_resp":               func(c *StatCounters) uint64 { return c.H2.RstStreamResp },
+	"h2_goaway_resp":                   func(c *StatCounters) uint64 { return c.H2.GoawayResp },
+	"h2_total_connections":             func(c *StatCounters) uint64 { return c.H2.TotalConnections },
+	"h2_backend_total_streams":         func(c *StatCounters) uint64 { return c.H2.BackendTotalStreams },
+	"h1_total_connections":             func(c *StatCounters) uint64 { return c.H1.TotalConnections },
+	"h1_total_streams":                 func(c *StatCounters) uint64 { return c.H1.TotalStreams },
+	"h1_bytes_in":                      func(c *StatCounters) uint64 { return c.H1.BytesIn },
+	"h1_bytes_out":                     func(c *StatCounters) uint64 { return c.H1.BytesOut },
+	"h1_spliced_bytes_in":              func(c *StatCounters) uint64 { return c.H1.SplicedBytesIn },
+	"h1_spliced_bytes_out":             func(c *StatCounters) uint64 { return c.H1.SplicedBytesOut },
+}
+
+// reports if the show stat column is a cumulative counter, as opposed to a gauge or a text
+func IsCumulative(column string) bool {
+	_, ok := cumulative[column]
+	return ok
+}
+
+// compute the change of the cumulative counters between two snapshots of show stat taken
+// elapsed apart. When a counter of an object has decreased the counters were reset, by
+// clear counters or a restart of HA-Proxy, and the increase is counted from zero
+func Diff(prev, cur []StatCounters, elapsed time.Duration) *SnapshotDiff {
+	previous := make(map[Key]*StatCounters, len(prev))
+	for i := range prev {
+		previous[KeyOf(&prev[i])] = &prev[i]
+	}
+
+	diff := &SnapshotDiff{}
+	seen := make(map[Key]bool, len(cur))
+	for i := range cur {
+		c := &cur[i]
+		key := KeyOf(c)
+		seen[key] = true
+
+		p, ok := previous[key]
+		if !ok {
+			diff.Appeared = append(diff.Appeared, *c)
+			continue
+		}
+		diff.Deltas = append(diff.Deltas, delta(key, p, c, elapsed))
+	}
+
+	for i := range prev {
+		if !seen[KeyOf(&prev[i])] {
+			diff.Disappeared = append(diff.Disappeared, prev[i])
+		}
+	}
+	return diff
+}
+
+func delta(key Key, prev, cur *StatCounters, elapsed time.Duration) Delta {
+	d := Delta{
+		Key:      key,
+		Elapsed:  elapsed,
+		Counters: make(map[string]uint64, len(cumulative)),
+		Current:  *cur,
+	}
+	for _, get := range cumulative {
+		if get(cur) < get(prev) {
+			d.Reset = true
+			break
+		}
+	}
+	for column, get := range cumulative {
+		if d.Reset {
+			d.Counters[column] = get(cur)
+		} else {
+			d.Counters[column] = get(cur) - get(prev)
+		}
+	}
+	return d
+}
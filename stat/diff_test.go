@@ -0,0 +1,64 @@
+package stat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	prev := []StatCounters{
+		{PxName: "indexws", SvName: "iws01", Type: ObjectTypeServer, Stot: 100, Bin: 1000, Hrsp5xx: 2},
+		{PxName: "indexws", SvName: "iws02", Type: ObjectTypeServer, Stot: 50},
+		{PxName: "indexws", SvName: "BACKEND", Type: ObjectTypeBackend, Stot: 150},
+	}
+	cur := []StatCounters{
+		{PxName: "indexws", SvName: "iws01", Type: ObjectTypeServer, Stot: 160, Bin: 1500, Hrsp5xx: 2, Scur: 3},
+		{PxName: "indexws", SvName: "iws03", Type: ObjectTypeServer, Stot: 5},
+		{PxName: "indexws", SvName: "BACKEND", Type: ObjectTypeBackend, Stot: 165},
+	}
+
+	diff := Diff(prev, cur, 10*time.Second)
+	if len(diff.Deltas) != 2 || len(diff.Appeared) != 1 || len(diff.Disappeared) != 1 {
+		t.Fatalf("unexpected diff: %d deltas, %d appeared, %d disappeared", len(diff.Deltas), len(diff.Appeared), len(diff.Disappeared))
+	}
+	if diff.Appeared[0].SvName != "iws03" || diff.Disappeared[0].SvName != "iws02" {
+		t.Fatalf("unexpected appeared %s and disappeared %s", diff.Appeared[0].SvName, diff.Disappeared[0].SvName)
+	}
+
+	d := diff.Deltas[0]
+	if d.Key != (Key{PxName: "indexws", SvName: "iws01", Type: ObjectTypeServer}) || d.Reset {
+		t.Fatalf("unexpected delta: %+v", d)
+	}
+	if d.Counters["stot"] != 60 || d.Counters["bin"] != 500 || d.Counters["hrsp_5xx"] != 0 || d.Current.Scur != 3 {
+		t.Fatalf("unexpected counters: %v", d.Counters)
+	}
+	if d.Rate("stot") != 6 || d.Rate("bin") != 50 {
+		t.Fatalf("unexpected rates %f and %f", d.Rate("stot"), d.Rate("bin"))
+	}
+	if diff.Deltas[1].Counters["stot"] != 15 {
+		t.Fatalf("unexpected backend delta: %v", diff.Deltas[1].Counters)
+	}
+}
+
+func TestDiffReset(t *testing.T) {
+	prev := []StatCounters{{PxName: "indexws", SvName: "iws01", Stot: 100, Bin: 1000}}
+	cur := []StatCounters{{PxName: "indexws", SvName: "iws01", Stot: 7, Bin: 1200}}
+
+	d := Diff(prev, cur, 0).Deltas[0]
+	if !d.Reset {
+		t.Fatalf("expected reset")
+	}
+	// all counters are counted from zero as they are reset together
+	if d.Counters["stot"] != 7 || d.Counters["bin"] != 1200 {
+		t.Fatalf("unexpected counters after reset: %v", d.Counters)
+	}
+	if d.Rate("stot") != 0 {
+		t.Fatalf("expected no rate without elapsed time")
+	}
+}
+
+func TestIsCumulative(t *testing.T) {
+	if !IsCumulative("stot") || !IsCumulative("h1_bytes_out") || IsCumulative("scur") || IsCumulative("status") {
+		t.Fatalf("unexpected cumulative columns")
+	}
+}
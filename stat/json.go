@@ -0,0 +1,151 @@
+package stat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// a field of the show stat json output, e.g.
+// {"objType":"Frontend","proxyId":2,"id":0,"field":{"pos":4,"name":"scur"},"processNum":1,
+// "tags":{"origin":"Metric","nature":"Gauge","scope":"Process"},"value":{"type":"u32","value":2}}
+// Reference: http://docs.haproxy.org/2.6/management.html#9.3 (show stat json)
+type jsonField struct {
+	ObjType string `json:"objType"`
+	ProxyID int    `json:"proxyId"`
+	ID      int    `json:"id"`
+	Field   struct {
+		Pos  int    `json:"pos"`
+		Name string `json:"name"`
+	} `json:"field"`
+	ProcessNum int `json:"processNum"`
+	Tags       struct {
+		Origin string `json:"origin"`
+		Nature string `json:"nature"`
+		Scope  string `json:"scope"`
+	} `json:"tags"`
+	Value struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"value"`
+}
+
+// the error object written instead of the fields when the output could not be produced
+type jsonError struct {
+	ErrorStr string `json:"errorStr"`
+}
+
+// object types and tags by the names used in the json output
+var (
+	jsonObjectTypes = map[string]ObjectType{
+		"Frontend": ObjectTypeFrontend,
+		"Backend":  ObjectTypeBackend,
+		"Server":   ObjectTypeServer,
+		"Listener": ObjectTypeListener,
+	}
+	jsonOrigins = map[string]Origin{
+		"Metric":  OriginMetric,
+		"Status":  OriginStatus,
+		"Key":     OriginKey,
+		"Config":  OriginConfig,
+		"Product": OriginProduct,
+	}
+	jsonNatures = map[string]Nature{
+		"Age":      NatureAge,
+		"Avg":      NatureAvg,
+		"Counter":  NatureCounter,
+		"Duration": NatureDuration,
+		"Gauge":    NatureGauge,
+		"Limit":    NatureLimit,
+		"Max":      NatureMax,
+		"Name":     NatureName,
+		"Output":   NatureOutput,
+		"Rate":     NatureRate,
+		"Time":     NatureTime,
+		"Start":    NatureTime,
+	}
+	jsonScopes = map[string]Scope{
+		"Global":  ScopeGlobal,
+		"Process": ScopeProcess,
+		"Service": ScopeService,
+		"System":  ScopeSystem,
+	}
+)
+
+// parse the response of the command show stat json from the Runtime API into the same
+// fields as the typed output. The fields can be converted with TypedToStatCounters
+func ParseShowStatJSON(response []byte) ([]TypedField, error) {
+	var objects [][]jsonField
+	if err := unmarshalJSON(response, &objects); err != nil {
+		return nil, err
+	}
+
+	var fields []TypedField
+	for _, object := range objects {
+		for _, jf := range object {
+			f, err := jf.typedField()
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// unmarshal the json output, HA-Proxy writes an object with an error text instead of
+// the array of fields when the output fails, e.g. when the buffer is too small
+func unmarshalJSON(response []byte, v any) error {
+	trimmed := bytes.TrimSpace(response)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var e jsonError
+		if err := json.Unmarshal(trimmed, &e); err != nil {
+			return &ParseError{Value: string(trimmed), Err: err}
+		}
+		return fmt.Errorf("json output failed: %s", e.ErrorStr)
+	}
+	if err := json.Unmarshal(trimmed, v); err != nil {
+		return &ParseError{Err: err}
+	}
+	return nil
+}
+
+func (jf *jsonField) typedField() (TypedField, error) {
+	f := TypedField{
+		ProxyID:   jf.ProxyID,
+		ObjectID:  jf.ID,
+		Position:  jf.Field.Pos,
+		Name:      jf.Field.Name,
+		Process:   jf.ProcessNum,
+		ValueType: ValueType(jf.Value.Type),
+	}
+	var ok bool
+	if f.ObjectType, ok = jsonObjectTypes[jf.ObjType]; !ok {
+		return TypedField{}, jf.parseError("objType", jf.ObjType, errors.New("unknown object type"))
+	}
+	if f.Origin, ok = jsonOrigins[jf.Tags.Origin]; !ok {
+		return TypedField{}, jf.parseError("origin", jf.Tags.Origin, errors.New("unknown origin"))
+	}
+	if f.Nature, ok = jsonNatures[jf.Tags.Nature]; !ok {
+		return TypedField{}, jf.parseError("nature", jf.Tags.Nature, errors.New("unknown nature"))
+	}
+	if f.Scope, ok = jsonScopes[jf.Tags.Scope]; !ok {
+		return TypedField{}, jf.parseError("scope", jf.Tags.Scope, errors.New("unknown scope"))
+	}
+
+	// numbers are written as json numbers and kept as their text like in the other formats
+	raw := bytes.TrimSpace(jf.Value.Value)
+	if bytes.HasPrefix(raw, []byte(`"`)) {
+		if err := json.Unmarshal(raw, &f.Value); err != nil {
+			return TypedField{}, jf.parseError(jf.Field.Name, string(raw), err)
+		}
+	} else {
+		f.Value = string(raw)
+	}
+	return f, nil
+}
+
+func (jf *jsonField) parseError(column, value string, err error) *ParseError {
+	return &ParseError{Column: column, Value: value, Err: fmt.Errorf("field %s: %w", jf.Field.Name, err)}
+}
@@ -0,0 +1,77 @@
+package stat
+
+import (
+	"errors"
+	"testing"
+)
+
+const showStatJSON = `[[
+{"objType":"Frontend","proxyId":2,"id":0,"field":{"pos":0,"name":"pxname"},"processNum":1,"tags":{"origin":"Key","nature":"Name","scope":"Service"},"value":{"type":"str","value":"stats"}},
+{"objType":"Frontend","proxyId":2,"id":0,"field":{"pos":1,"name":"svname"},"processNum":1,"tags":{"origin":"Key","nature":"Name","scope":"Service"},"value":{"type":"str","value":"FRONTEND"}},
+{"objType":"Frontend","proxyId":2,"id":0,"field":{"pos":4,"name":"scur"},"processNum":1,"tags":{"origin":"Metric","nature":"Gauge","scope":"Process"},"value":{"type":"u32","value":2}},
+{"objType":"Frontend","proxyId":2,"id":0,"field":{"pos":17,"name":"status"},"processNum":1,"tags":{"origin":"Status","nature":"Output","scope":"Process"},"value":{"type":"str","value":"OPEN"}}
+],[
+{"objType":"Server","proxyId":4,"id":1,"field":{"pos":0,"name":"pxname"},"processNum":1,"tags":{"origin":"Key","nature":"Name","scope":"Service"},"value":{"type":"str","value":"indexws"}},
+{"objType":"Server","proxyId":4,"id":1,"field":{"pos":1,"name":"svname"},"processNum":1,"tags":{"origin":"Key","nature":"Name","scope":"Service"},"value":{"type":"str","value":"iws01"}},
+{"objType":"Server","proxyId":4,"id":1,"field":{"pos":7,"name":"stot"},"processNum":1,"tags":{"origin":"Metric","nature":"Counter","scope":"Process"},"value":{"type":"u64","value":7257}},
+{"objType":"Server","proxyId":4,"id":1,"field":{"pos":73,"name":"addr"},"processNum":1,"tags":{"origin":"Config","nature":"Gauge","scope":"Service"},"value":{"type":"str","value":"172.24.21.40:8080"}}
+]]
+`
+
+func TestParseShowStatJSON(t *testing.T) {
+	fields, err := ParseShowStatJSON([]byte(showStatJSON))
+	if err != nil {
+		t.Fatalf("unable to parse json stat: %v", err)
+	}
+	if len(fields) != 8 {
+		t.Fatalf("expected 8 fields got %d", len(fields))
+	}
+
+	expected := TypedField{
+		ObjectType: ObjectTypeFrontend,
+		ProxyID:    2,
+		Position:   4,
+		Name:       "scur",
+		Process:    1,
+		Origin:     OriginMetric,
+		Nature:     NatureGauge,
+		Scope:      ScopeProcess,
+		ValueType:  ValueTypeU32,
+		Value:      "2",
+	}
+	if fields[2] != expected {
+		t.Fatalf("unexpected scur field: %+v", fields[2])
+	}
+
+	stats, err := TypedToStatCounters(fields)
+	if err != nil {
+		t.Fatalf("unable to convert json fields: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 objects got %d", len(stats))
+	}
+	if stats[0].SvName != "FRONTEND" || stats[0].Scur != 2 || stats[0].Status != "OPEN" {
+		t.Fatalf("unexpected frontend: %+v", stats[0])
+	}
+	if stats[1].SvName != "iws01" || stats[1].Stot != 7257 || stats[1].Addr != "172.24.21.40:8080" {
+		t.Fatalf("unexpected server: %+v", stats[1])
+	}
+}
+
+func TestParseShowStatJSONErrors(t *testing.T) {
+	_, err := ParseShowStatJSON([]byte(`{"errorStr":"output buffer too short"}`))
+	if err == nil || err.Error() != "json output failed: output buffer too short" {
+		t.Fatalf("expected the error of the output got: %v", err)
+	}
+
+	var perr *ParseError
+	_, err = ParseShowStatJSON([]byte(`[[{"objType":"Frontend"`))
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected parse error for truncated output got: %v", err)
+	}
+
+	_, err = ParseShowStatJSON([]byte(`[[{"objType":"Ring","field":{"pos":0,"name":"pxname"}}]]`))
+	if !errors.As(err, &perr) || perr.Column != "objType" || perr.Value != "Ring" {
+		t.Fatalf("expected parse error for the object type got: %v", err)
+	}
+}
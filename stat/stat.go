@@ -4,274 +4,420 @@ package stat
 import (
 	"bytes"
 	"encoding/csv"
+	"io"
 	"strconv"
+	"strings"
 )
 
 // represents http://docs.haproxy.org/2.6/management.html#9.1
 // The letters in brackets are L (Listeners), F (Frontends), B (Backends), and S (Servers) indicating the type which may have a value
-// below is made for the following response header - not all are mapped - last included is uweight
+// the columns are mapped by the names in the response header and the numbers below are the positions
+// in the following HA-Proxy 2.6 response header - the counters of the proxy modules are grouped
+// in the SSL, H2 and H1 structs, and columns not mapped like those of later versions are found in Extra
 // pxname,svname,qcur,qmax,scur,smax,slim,stot,bin,bout,dreq,dresp,ereq,econ,eresp,wretr,wredis,status,weight,act,bck,chkfail,chkdown,lastchg,downtime,qlimit,pid,iid,sid,throttle,lbtot,tracked,type,rate,rate_lim,rate_max,check_status,check_code,check_duration,hrsp_1xx,hrsp_2xx,hrsp_3xx,hrsp_4xx,hrsp_5xx,hrsp_other,hanafail,req_rate,req_rate_max,req_tot,cli_abrt,srv_abrt,comp_in,comp_out,comp_byp,comp_rsp,lastsess,last_chk,last_agt,qtime,ctime,rtime,ttime,agent_status,agent_code,agent_duration,check_desc,agent_desc,check_rise,check_fall,check_health,agent_rise,agent_fall,agent_health,addr,cookie,mode,algo,conn_rate,conn_rate_max,conn_tot,intercepted,dcon,dses,wrew,connect,reuse,cache_lookups,cache_hits,srv_icur,src_ilim,qtime_max,ctime_max,rtime_max,ttime_max,eint,idle_conn_cur,safe_conn_cur,used_conn_cur,need_conn_est,uweight,agg_server_status,agg_server_check_status,agg_check_status,-,ssl_sess,ssl_reused_sess,ssl_failed_handshake,h2_headers_rcvd,h2_data_rcvd,h2_settings_rcvd,h2_rst_stream_rcvd,h2_goaway_rcvd,h2_detected_conn_protocol_errors,h2_detected_strm_protocol_errors,h2_rst_stream_resp,h2_goaway_resp,h2_open_connections,h2_backend_open_streams,h2_total_connections,h2_backend_total_streams,h1_open_connections,h1_open_streams,h1_total_connections,h1_total_streams,h1_bytes_in,h1_bytes_out,h1_spliced_bytes_in,h1_spliced_bytes_out,
 type StatCounters struct {
-	PxName        string // 0: Proxy name [LFBS]
-	SvName        string // 1: service name (FRONTEND for frontend, BACKEND for backend, any name for server/listener) [LFBS]
-	Qcur          uint32 // 2: current queued requests. For the backend this reports the number queued without a server assigned. [..BS]
-	Qmax          uint32 // 3: max value of qcur [..BS]
-	Scur          uint32 // 4: current sessions [LFBS]
-	Smax          uint32 // 5: max sessions [LFBS]
-	Slim          uint32 // 6: configured session limit [LFBS]
-	Stot          uint64 // 7: cumulative number of sessions [LFBS]
-	Bin           uint64 // 8: bytes in [LFBS]
-	Bout          uint64 // 9: bytes out [LFBS]
-	Dreg          uint64 // 10: requests denied because of security concerns. [LFB.]
-	Dresp         uint64 // 11: responses denied because of security concerns. [LFBS]
-	Ereg          uint64 // 12: request errors. [LF..]
-	Econ          uint64 // 13:  number of requests that encountered an error trying to connect to a backend server. [..BS] The backend stat is the sum of the stat for all servers of that backend, plus any connection errors not associated with a particular server (such as the backend having no active servers)
-	Eresp         uint64 // 14: response errors. [..BS]
-	Wretr         uint64 // 15: number of times a connection to a server was retried. [..BS]
-	Wredis        uint64 // 16: number of times a request was redispatched to another server. The server value counts the number of times that server was switched away from. [..BS]
-	Status        string // 17: status (UP/DOWN/NOLB/MAINT/MAINT(via)/MAINT(resolution)...) [LFBS]
-	Weight        uint32 // 18: total effective weight (backend), effective weight (server) [..BS]
-	Act           uint32 // 19: number of active servers (backend), server is active (server) [..BS]
-	Bck           uint32 // 20: number of backup servers (backend), server is backup (server) [..BS]
-	ChkFail       uint64 // 21: number of failed checks. (Only counts checks failed when the server is up.) [...S]
-	ChkDown       uint64 // 22: number of UP->DOWN transitions. The backend counter counts transitions to the whole backend being down, rather than the sum of the counters for each server. [..BS]
-	LastChg       uint32 // 23: number of seconds since the last UP<->DOWN transition [..BS]
-	Downtime      uint32 // 24: total downtime (in seconds). The value for the backend is the downtime for the whole backend, not the sum of the server downtime. [..BS]
-	Qlimit        uint64 // 25: configured maxqueue for the server, or nothing in the value is 0 (default, meaning no limit) [...S]
-	Pid           uint32 // 26: process id (0 for first instance, 1 for second, ...) [LFBS]
-	Iid           uint32 // 27: unique proxy id [LFBS]
-	Sid           uint32 // 28: server id (unique inside a proxy)[L..S]
-	Throttle      uint64 // 29: current throttle percentage for the server, when slowstart is active, or no value if not in slowstart.
-	Lbtot         uint64 // 30: total number of times a server was selected, either for new sessions, or when re-dispatching. The server counter is the number of times that server was selected. [..BS]
-	Tracked       uint32 // 31: id of proxy/server if tracking is enabled. [...S]
-	Type          uint32 // 32: (0=frontend, 1=backend, 2=server, 3=socket/listener) [LFBS]
-	Rate          uint32 // 33: number of sessions per second over last elapsed second [.FBS]
-	RateLim       uint32 // 34: configured limit on new sessions per second [.F..]
-	RateMax       uint32 // 35: max number of new sessions per second [.FBS]
-	CheckStatus   string // 36: status of last health check. [...S] Notice: If a check is currently running, the last known status will be reported, prefixed with "* ". e. g. "* L7OK".
-	CheckCode     uint32 // 37: layer5-7 code, if available HTTP/SMTP/LDAP status code reported by the latest server health check [...S]
-	CheckDuration uint64 // 38: time in ms took to finish last health check [...S] Total duration of the latest server health check, in milliseconds
-	Hrsp1xx       uint64 // 39: http responses with 1xx code [.FBS]
-	Hrsp2xx       uint64 // 40: http responses with 2xx code [.FBS]
-	Hrsp3xx       uint64 // 41: http responses with 3xx code [.FBS]
-	Hrsp4xx       uint64 // 42: http responses with 4xx code [.FBS]
-	Hrsp5xx       uint64 // 43: http responses with 5xx code [.FBS]
-	HrspOther     uint64 // 44: http responses with other codes (protocol error) [.FBS]
-	HanaFail      uint64 // 45: failed health checks details [...S]
-	ReqRate       uint32 // 46: HTTP requests per second over last elapsed second [.F..]
-	ReqRateMax    uint32 // 47: max number of HTTP requests per second observed [.F..]
-	ReqTot        uint64 // 48: total number of HTTP requests received [.FB.]
-	CliAbrt       uint64 // 49: number of data transfers aborted by the client [..BS]
-	SrvAbrt       uint64 // 50: number of data transfers aborted by the server (inc. in eresp) [..BS]
-	CompIn        uint64 // 51: number of HTTP response bytes fed to the compressor [.FB.]
-	CompOut       uint64 // 52: number of HTTP response bytes emitted by the compressor [.FB.]
-	CompByp       uint64 // 53: number of bytes that bypassed the HTTP compressor (CPU/BW limit) [.FB.]
-	CompRsp       uint64 // 54: number of HTTP responses that were compressed [.FB.]
-	LastSess      int    // 55: number of seconds since last session assigned to server/backend [..BS]
-	LastChk       string // 56: last health check contents or textual error [...S]
-	LastAgt       string // 57: last agent check contents or textual error [...S]
-	Qtime         uint32 // 58: the average queue time in ms over the 1024 last requests [..BS]
-	Ctime         uint32 // 59: the average connect time in ms over the 1024 last requests [..BS]
-	Rtime         uint32 // 60: the average response time in ms over the 1024 last requests (0 for TCP) [..BS]
-	Ttime         uint32 // 61: the average total session time in ms over the 1024 last requests [..BS]
-	AgentStatus   string // 62: status of last agent check [...S]
-	AgentCode     uint32 // 63: numeric code reported by agent if any (unused for now) [...S]
-	AgentDuration uint64 // 64: time in ms taken to finish last check [...S]
-	CheckDesc     string // 65: short human-readable description of check_status [...S]
-	AgentDesc     string // 66: short human-readable description of agent_status [...S]
-	CheckRise     uint32 // 67: server's "rise" parameter used by checks, number of successful health checks before declaring a server UP (server 'rise' setting) [...S]
-	CheckFall     uint32 // 68: server's "fall" parameter used by checks, number of failed health checks before declaring a server DOWN (server 'fall' setting) [...S]
-	CheckHealth   uint32 // 69: server's health check value between 0 and rise+fall-1, current server health check level (0..fall-1=DOWN, fall..rise-1=UP) [...S]
-	AgentRise     uint32 // 70: agent's "rise" parameter, normally 1 [...S]
-	AgentFall     uint32 // 71: agent's "fall" parameter, normally 1 [...S]
-	AgentHealth   uint32 // 72: agent's health parameter, between 0 and rise+fall-1 [...S]
-	Addr          string // 73: address:port or "unix". IPv6 has brackets around the address. [L..S]
-	Cookie        string // 74: server's cookie value or backend's cookie name [..BS]
-	Mode          string // 75: proxy mode (tcp, http, health, unknown) [LFBS]
-	Algo          string // 76: load balancing algorithm [..B.]
-	ConnRate      uint32 // 77: number of connections over the last elapsed second [.F..]
-	ConnRateMax   uint32 // 78: highest known conn_rate [.F..]
-	ConnTot       uint64 // 79: cumulative number of connections [.F..]
-	Intercepted   uint64 // 80: Total number of HTTP requests intercepted on the frontend (redirects/stats/services) since the worker process started [.FB.]
-	Dcon          uint64 // 81: requests denied by "tcp-request connection" rules [LF..]
-	Dses          uint64 // 82: requests denied by "tcp-request session" rules  [LF..]
-	Wrew          uint64 // 83: cumulative number of failed header rewriting warnings [LFBS]
-	Connect       uint64 // 84: cumulative number of connection establishment attempts [..BS]
-	Reuse         uint64 // 85: cumulative number of connection reuses [..BS]
-	CacheLookups  uint64 // 86: cumulative number of cache lookups [.FB.]
-	CacheHits     uint64 // 87: cumulative number of cache hits [.FB.]
-	SrvIcur       uint32 // 88: current number of idle connections available for reuse [...S]
-	SrcIlim       uint32 // 89: limit on the number of available idle connections [...S]
-	QtimeMax      uint32 // 90: the maximum observed queue time in ms [..BS]
-	CtimeMax      uint32 // 91: the maximum observed connect time in ms [..BS]
-	RtimeMax      uint32 // 92: the maximum observed response time in ms (0 for TCP) [..BS]
-	TtimeMax      uint32 // 93: the maximum observed total session time in ms [..BS]
-	Eint          uint64 // 94: cumulative number of internal errors [LFBS]
-	IdleConnCur   uint32 // 95: current number of unsafe idle connections [...S]
-	SafeConnCur   uint32 // 96: current number of safe idle connections [...S]
-	UsedConnCur   uint32 // 97: current number of connections in use [...S]
-	NeedConnEst   uint32 // 98: estimated needed number of connections [...S]
-	Uweight       uint32 // 99: total user weight (backend), server user weight (server) [..BS]
+	PxName        string      // 0: Proxy name [LFBS]
+	SvName        string      // 1: service name (FRONTEND for frontend, BACKEND for backend, any name for server/listener) [LFBS]
+	Qcur          uint32      // 2: current queued requests. For the backend this reports the number queued without a server assigned. [..BS]
+	Qmax          uint32      // 3: max value of qcur [..BS]
+	Scur          uint32      // 4: current sessions [LFBS]
+	Smax          uint32      // 5: max sessions [LFBS]
+	Slim          uint32      // 6: configured session limit [LFBS]
+	Stot          uint64      // 7: cumulative number of sessions [LFBS]
+	Bin           uint64      // 8: bytes in [LFBS]
+	Bout          uint64      // 9: bytes out [LFBS]
+	Dreg          uint64      // 10: requests denied because of security concerns. [LFB.]
+	Dresp         uint64      // 11: responses denied because of security concerns. [LFBS]
+	Ereg          uint64      // 12: request errors. [LF..]
+	Econ          uint64      // 13:  number of requests that encountered an error trying to connect to a backend server. [..BS] The backend stat is the sum of the stat for all servers of that backend, plus any connection errors not associated with a particular server (such as the backend having no active servers)
+	Eresp         uint64      // 14: response errors. [..BS]
+	Wretr         uint64      // 15: number of times a connection to a server was retried. [..BS]
+	Wredis        uint64      // 16: number of times a request was redispatched to another server. The server value counts the number of times that server was switched away from. [..BS]
+	Status        Status      // 17: status (UP/DOWN/NOLB/MAINT/MAINT(via)/MAINT(resolution)...) [LFBS]
+	Weight        uint32      // 18: total effective weight (backend), effective weight (server) [..BS]
+	Act           uint32      // 19: number of active servers (backend), server is active (server) [..BS]
+	Bck           uint32      // 20: number of backup servers (backend), server is backup (server) [..BS]
+	ChkFail       uint64      // 21: number of failed checks. (Only counts checks failed when the server is up.) [...S]
+	ChkDown       uint64      // 22: number of UP->DOWN transitions. The backend counter counts transitions to the whole backend being down, rather than the sum of the counters for each server. [..BS]
+	LastChg       uint32      // 23: number of seconds since the last UP<->DOWN transition [..BS]
+	Downtime      uint32      // 24: total downtime (in seconds). The value for the backend is the downtime for the whole backend, not the sum of the server downtime. [..BS]
+	Qlimit        uint64      // 25: configured maxqueue for the server, or nothing in the value is 0 (default, meaning no limit) [...S]
+	Pid           uint32      // 26: process id (0 for first instance, 1 for second, ...) [LFBS]
+	Iid           uint32      // 27: unique proxy id [LFBS]
+	Sid           uint32      // 28: server id (unique inside a proxy)[L..S]
+	Throttle      uint64      // 29: current throttle percentage for the server, when slowstart is active, or no value if not in slowstart.
+	Lbtot         uint64      // 30: total number of times a server was selected, either for new sessions, or when re-dispatching. The server counter is the number of times that server was selected. [..BS]
+	Tracked       uint32      // 31: id of proxy/server if tracking is enabled. [...S]
+	Type          ObjectType  // 32: (0=frontend, 1=backend, 2=server, 3=socket/listener) [LFBS]
+	Rate          uint32      // 33: number of sessions per second over last elapsed second [.FBS]
+	RateLim       uint32      // 34: configured limit on new sessions per second [.F..]
+	RateMax       uint32      // 35: max number of new sessions per second [.FBS]
+	CheckStatus   CheckStatus // 36: status of last health check. [...S] Notice: If a check is currently running, the last known status will be reported, prefixed with "* ". e. g. "* L7OK".
+	CheckCode     uint32      // 37: layer5-7 code, if available HTTP/SMTP/LDAP status code reported by the latest server health check [...S]
+	CheckDuration uint64      // 38: time in ms took to finish last health check [...S] Total duration of the latest server health check, in milliseconds
+	Hrsp1xx       uint64      // 39: http responses with 1xx code [.FBS]
+	Hrsp2xx       uint64      // 40: http responses with 2xx code [.FBS]
+	Hrsp3xx       uint64      // 41: http responses with 3xx code [.FBS]
+	Hrsp4xx       uint64      // 42: http responses with 4xx code [.FBS]
+	Hrsp5xx       uint64      // 43: http responses with 5xx code [.FBS]
+	HrspOther     uint64      // 44: http responses with other codes (protocol error) [.FBS]
+	HanaFail      uint64      // 45: failed health checks details [...S]
+	ReqRate       uint32      // 46: HTTP requests per second over last elapsed second [.F..]
+	ReqRateMax    uint32      // 47: max number of HTTP requests per second observed [.F..]
+	ReqTot        uint64      // 48: total number of HTTP requests received [.FB.]
+	CliAbrt       uint64      // 49: number of data transfers aborted by the client [..BS]
+	SrvAbrt       uint64      // 50: number of data transfers aborted by the server (inc. in eresp) [..BS]
+	CompIn        uint64      // 51: number of HTTP response bytes fed to the compressor [.FB.]
+	CompOut       uint64      // 52: number of HTTP response bytes emitted by the compressor [.FB.]
+	CompByp       uint64      // 53: number of bytes that bypassed the HTTP compressor (CPU/BW limit) [.FB.]
+	CompRsp       uint64      // 54: number of HTTP responses that were compressed [.FB.]
+	LastSess      int         // 55: number of seconds since last session assigned to server/backend [..BS]
+	LastChk       string      // 56: last health check contents or textual error [...S]
+	LastAgt       string      // 57: last agent check contents or textual error [...S]
+	Qtime         uint32      // 58: the average queue time in ms over the 1024 last requests [..BS]
+	Ctime         uint32      // 59: the average connect time in ms over the 1024 last requests [..BS]
+	Rtime         uint32      // 60: the average response time in ms over the 1024 last requests (0 for TCP) [..BS]
+	Ttime         uint32      // 61: the average total session time in ms over the 1024 last requests [..BS]
+	AgentStatus   CheckStatus // 62: status of last agent check [...S]
+	AgentCode     uint32      // 63: numeric code reported by agent if any (unused for now) [...S]
+	AgentDuration uint64      // 64: time in ms taken to finish last check [...S]
+	CheckDesc     string      // 65: short human-readable description of check_status [...S]
+	AgentDesc     string      // 66: short human-readable description of agent_status [...S]
+	CheckRise     uint32      // 67: server's "rise" parameter used by checks, number of successful health checks before declaring a server UP (server 'rise' setting) [...S]
+	CheckFall     uint32      // 68: server's "fall" parameter used by checks, number of failed health checks before declaring a server DOWN (server 'fall' setting) [...S]
+	CheckHealth   uint32      // 69: server's health check value between 0 and rise+fall-1, current server health check level (0..fall-1=DOWN, fall..rise-1=UP) [...S]
+	AgentRise     uint32      // 70: agent's "rise" parameter, normally 1 [...S]
+	AgentFall     uint32      // 71: agent's "fall" parameter, normally 1 [...S]
+	AgentHealth   uint32      // 72: agent's health parameter, between 0 and rise+fall-1 [...S]
+	Addr          string      // 73: address:port or "unix". IPv6 has brackets around the address. [L..S]
+	Cookie        string      // 74: server's cookie value or backend's cookie name [..BS]
+	Mode          Mode        // 75: proxy mode (tcp, http, health, unknown) [LFBS]
+	Algo          Algo        // 76: load balancing algorithm [..B.]
+	ConnRate      uint32      // 77: number of connections over the last elapsed second [.F..]
+	ConnRateMax   uint32      // 78: highest known conn_rate [.F..]
+	ConnTot       uint64      // 79: cumulative number of connections [.F..]
+	Intercepted   uint64      // 80: Total number of HTTP requests intercepted on the frontend (redirects/stats/services) since the worker process started [.FB.]
+	Dcon          uint64      // 81: requests denied by "tcp-request connection" rules [LF..]
+	Dses          uint64      // 82: requests denied by "tcp-request session" rules  [LF..]
+	Wrew          uint64      // 83: cumulative number of failed header rewriting warnings [LFBS]
+	Connect       uint64      // 84: cumulative number of connection establishment attempts [..BS]
+	Reuse         uint64      // 85: cumulative number of connection reuses [..BS]
+	CacheLookups  uint64      // 86: cumulative number of cache lookups [.FB.]
+	CacheHits     uint64      // 87: cumulative number of cache hits [.FB.]
+	SrvIcur       uint32      // 88: current number of idle connections available for reuse [...S]
+	SrcIlim       uint32      // 89: limit on the number of available idle connections [...S]
+	QtimeMax      uint32      // 90: the maximum observed queue time in ms [..BS]
+	CtimeMax      uint32      // 91: the maximum observed connect time in ms [..BS]
+	RtimeMax      uint32      // 92: the maximum observed response time in ms (0 for TCP) [..BS]
+	TtimeMax      uint32      // 93: the maximum observed total session time in ms [..BS]
+	Eint          uint64      // 94: cumulative number of internal errors [LFBS]
+	IdleConnCur   uint32      // 95: current number of unsafe idle connections [...S]
+	SafeConnCur   uint32      // 96: current number of safe idle connections [...S]
+	UsedConnCur   uint32      // 97: current number of connections in use [...S]
+	NeedConnEst   uint32      // 98: estimated needed number of connections [...S]
+	Uweight       uint32      // 99: total user weight (backend), server user weight (server) [..BS]
+
+	AggServerStatus      uint32 // 100: backend's aggregated gauge of servers' status [..B.]
+	AggServerCheckStatus uint32 // 101: deprecated - backend's aggregated gauge of servers' state check status [..B.]
+	AggCheckStatus       uint32 // 102: backend's aggregated gauge of servers' state check status [..B.]
+
+	SSL SSLCounters // 104-106: counters of the SSL proxy module [.FBS]
+	H2  H2Counters  // 107-119: counters of the HTTP/2 proxy module [.FB.]
+	H1  H1Counters  // 120-127: counters of the HTTP/1 proxy module [.FB.]
+
+	Extra map[string]string // columns without a field above by the column name in the header
+}
+
+// counters of the SSL proxy module
+type SSLCounters struct {
+	Sess            uint64 // 104: ssl_sess total number of ssl sessions established
+	ReusedSess      uint64 // 105: ssl_reused_sess total number of ssl sessions reused
+	FailedHandshake uint64 // 106: ssl_failed_handshake total number of failed handshake
+}
+
+// counters of the HTTP/2 proxy module
+type H2Counters struct {
+	HeadersRcvd                uint64 // 107: h2_headers_rcvd total number of received HEADERS frames
+	DataRcvd                   uint64 // 108: h2_data_rcvd total number of received DATA frames
+	SettingsRcvd               uint64 // 109: h2_settings_rcvd total number of received SETTINGS frames
+	RstStreamRcvd              uint64 // 110: h2_rst_stream_rcvd total number of received RST_STREAM frames
+	GoawayRcvd                 uint64 // 111: h2_goaway_rcvd total number of received GOAWAY frames
+	DetectedConnProtocolErrors uint64 // 112: h2_detected_conn_protocol_errors total number of connection protocol errors
+	DetectedStrmProtocolErrors uint64 // 113: h2_detected_strm_protocol_errors total number of stream protocol errors
+	RstStreamResp              uint64 // 114: h2_rst_stream_resp total number of RST_STREAM sent on detected error
+	GoawayResp                 uint64 // 115: h2_goaway_resp total number of GOAWAY sent on detected error
+	OpenConnections            uint64 // 116: h2_open_connections count of currently open connections
+	BackendOpenStreams         uint64 // 117: h2_backend_open_streams count of currently open streams
+	TotalConnections           uint64 // 118: h2_total_connections total number of connections
+	BackendTotalStreams        uint64 // 119: h2_backend_total_streams total number of streams
+}
+
+// counters of the HTTP/1 proxy module
+type H1Counters struct {
+	OpenConnections  uint64 // 120: h1_open_connections count of currently open connections
+	OpenStreams      uint64 // 121: h1_open_streams count of currently open streams
+	TotalConnections uint64 // 122: h1_total_connections total number of connections
+	TotalStreams     uint64 // 123: h1_total_streams total number of streams
+	BytesIn          uint64 // 124: h1_bytes_in total number of bytes received
+	BytesOut         uint64 // 125: h1_bytes_out total number of bytes send
+	SplicedBytesIn   uint64 // 126: h1_spliced_bytes_in total number of bytes received using kernel splicing
+	SplicedBytesOut  uint64 // 127: h1_spliced_bytes_out total number of bytes sent using kernel splicing
+}
+
+// the show stat columns of HA-Proxy 2.6 used for responses without a header line
+var defaultHeader = strings.Split("pxname,svname,qcur,qmax,scur,smax,slim,stot,bin,bout,dreq,dresp,ereq,econ,eresp,wretr,wredis,status,weight,act,bck,chkfail,chkdown,lastchg,downtime,qlimit,pid,iid,sid,throttle,lbtot,tracked,type,rate,rate_lim,rate_max,check_status,check_code,check_duration,hrsp_1xx,hrsp_2xx,hrsp_3xx,hrsp_4xx,hrsp_5xx,hrsp_other,hanafail,req_rate,req_rate_max,req_tot,cli_abrt,srv_abrt,comp_in,comp_out,comp_byp,comp_rsp,lastsess,last_chk,last_agt,qtime,ctime,rtime,ttime,agent_status,agent_code,agent_duration,check_desc,agent_desc,check_rise,check_fall,check_health,agent_rise,agent_fall,agent_health,addr,cookie,mode,algo,conn_rate,conn_rate_max,conn_tot,intercepted,dcon,dses,wrew,connect,reuse,cache_lookups,cache_hits,srv_icur,src_ilim,qtime_max,ctime_max,rtime_max,ttime_max,eint,idle_conn_cur,safe_conn_cur,used_conn_cur,need_conn_est,uweight,agg_server_status,agg_server_check_status,agg_check_status,-,ssl_sess,ssl_reused_sess,ssl_failed_handshake,h2_headers_rcvd,h2_data_rcvd,h2_settings_rcvd,h2_rst_stream_rcvd,h2_goaway_rcvd,h2_detected_conn_protocol_errors,h2_detected_strm_protocol_errors,h2_rst_stream_resp,h2_goaway_resp,h2_open_connections,h2_backend_open_streams,h2_total_connections,h2_backend_total_streams,h1_open_connections,h1_open_streams,h1_total_connections,h1_total_streams,h1_bytes_in,h1_bytes_out,h1_spliced_bytes_in,h1_spliced_bytes_out", ",")
+
+// setters of the StatCounters fields by the column name used in the show stat header
+var columns = map[string]func(c *StatCounters, v string) error{
+	"pxname":                           func(c *StatCounters, v string) (err error) { c.PxName = v; return },
+	"svname":                           func(c *StatCounters, v string) (err error) { c.SvName = v; return },
+	"qcur":                             func(c *StatCounters, v string) (err error) { c.Qcur, err = u32(v); return },
+	"qmax":                             func(c *StatCounters, v string) (err error) { c.Qmax, err = u32(v); return },
+	"scur":                             func(c *StatCounters, v string) (err error) { c.Scur, err = u32(v); return },
+	"smax":                             func(c *StatCounters, v string) (err error) { c.Smax, err = u32(v); return },
+	"slim":                             func(c *StatCounters, v string) (err error) { c.Slim, err = u32(v); return },
+	"stot":                             func(c *StatCounters, v string) (err error) { c.Stot, err = u64(v); return },
+	"bin":                              func(c *StatCounters, v string) (err error) { c.Bin, err = u64(v); return },
+	"bout":                             func(c *StatCounters, v string) (err error) { c.Bout, err = u64(v); return },
+	"dreq":                             func(c *StatCounters, v string) (err error) { c.Dreg, err = u64(v); return },
+	"dresp":                            func(c *StatCounters, v string) (err error) { c.Dresp, err = u64(v); return },
+	"ereq":                             func(c *StatCounters, v string) (err error) { c.Ereg, err = u64(v); return },
+	"econ":                             func(c *StatCounters, v string) (err error) { c.Econ, err = u64(v); return },
+	"eresp":                            func(c *StatCounters, v string) (err error) { c.Eresp, err = u64(v); return },
+	"wretr":                            func(c *StatCounters, v string) (err error) { c.Wretr, err = u64(v); return },
+	"wredis":                           func(c *StatCounters, v string) (err error) { c.Wredis, err = u64(v); return },
+	"status":                           func(c *StatCounters, v string) (err error) { c.Status = Status(v); return },
+	"weight":                           func(c *StatCounters, v string) (err error) { c.Weight, err = u32(v); return },
+	"act":                              func(c *StatCounters, v string) (err error) { c.Act, err = u32(v); return },
+	"bck":                              func(c *StatCounters, v string) (err error) { c.Bck, err = u32(v); return },
+	"chkfail":                          func(c *StatCounters, v string) (err error) { c.ChkFail, err = u64(v); return },
+	"chkdown":                          func(c *StatCounters, v string) (err error) { c.ChkDown, err = u64(v); return },
+	"lastchg":                          func(c *StatCounters, v string) (err error) { c.LastChg, err = u32(v); return },
+	"downtime":                         func(c *StatCounters, v string) (err error) { c.Downtime, err = u32(v); return },
+	"qlimit":                           func(c *StatCounters, v string) (err error) { c.Qlimit, err = u64(v); return },
+	"pid":                              func(c *StatCounters, v string) (err error) { c.Pid, err = u32(v); return },
+	"iid":                              func(c *StatCounters, v string) (err error) { c.Iid, err = u32(v); return },
+	"sid":                              func(c *StatCounters, v string) (err error) { c.Sid, err = u32(v); return },
+	"throttle":                         func(c *StatCounters, v string) (err error) { c.Throttle, err = u64(v); return },
+	"lbtot":                            func(c *StatCounters, v string) (err error) { c.Lbtot, err = u64(v); return },
+	"tracked":                          func(c *StatCounters, v string) (err error) { c.Tracked, err = u32(v); return },
+	"type":                             func(c *StatCounters, v string) error { t, err := u32(v); c.Type = ObjectType(t); return err },
+	"rate":                             func(c *StatCounters, v string) (err error) { c.Rate, err = u32(v); return },
+	"rate_lim":                         func(c *StatCounters, v string) (err error) { c.RateLim, err = u32(v); return },
+	"rate_max":                         func(c *StatCounters, v string) (err error) { c.RateMax, err = u32(v); return },
+	"check_status":                     func(c *StatCounters, v string) (err error) { c.CheckStatus = CheckStatus(v); return },
+	"check_code":                       func(c *StatCounters, v string) (err error) { c.CheckCode, err = u32(v); return },
+	"check_duration":                   func(c *StatCounters, v string) (err error) { c.CheckDuration, err = u64(v); return },
+	"hrsp_1xx":                         func(c *StatCounters, v string) (err error) { c.Hrsp1xx, err = u64(v); return },
+	"hrsp_2xx":                         func(c *StatCounters, v string) (err error) { c.Hrsp2xx, err = u64(v); return },
+	"hrsp_3xx":                         func(c *StatCounters, v string) (err error) { c.Hrsp3xx, err = u64(v); return },
+	"hrsp_4xx":                         func(c *StatCounters, v string) (err error) { c.Hrsp4xx, err = u64(v); return },
+	"hrsp_5xx":                         func(c *StatCounters, v string) (err error) { c.Hrsp5xx, err = u64(v); return },
+	"hrsp_other":                       func(c *StatCounters, v string) (err error) { c.HrspOther, err = u64(v); return },
+	"hanafail":                         func(c *StatCounters, v string) (err error) { c.HanaFail, err = u64(v); return },
+	"req_rate":                         func(c *StatCounters, v string) (err error) { c.ReqRate, err = u32(v); return },
+	"req_rate_max":                     func(c *StatCounters, v string) (err error) { c.ReqRateMax, err = u32(v); return },
+	"req_tot":                          func(c *StatCounters, v string) (err error) { c.ReqTot, err = u64(v); return },
+	"cli_abrt":                         func(c *StatCounters, v string) (err error) { c.CliAbrt, err = u64(v); return },
+	"srv_abrt":                         func(c *StatCounters, v string) (err error) { c.SrvAbrt, err = u64(v); return },
+	"comp_in":                          func(c *StatCounters, v string) (err error) { c.CompIn, err = u64(v); return },
+	"comp_out":                         func(c *StatCounters, v string) (err error) { c.CompOut, err = u64(v); return },
+	"comp_byp":                         func(c *StatCounters, v string) (err error) { c.CompByp, err = u64(v); return },
+	"comp_rsp":                         func(c *StatCounters, v string) (err error) { c.CompRsp, err = u64(v); return },
+	"lastsess":                         func(c *StatCounters, v string) (err error) { c.LastSess, err = atoi(v); return },
+	"last_chk":                         func(c *StatCounters, v string) (err error) { c.LastChk = v; return },
+	"last_agt":                         func(c *StatCounters, v string) (err error) { c.LastAgt = v; return },
+	"qtime":                            func(c *StatCounters, v string) (err error) { c.Qtime, err = u32(v); return },
+	"ctime":                            func(c *StatCounters, v string) (err error) { c.Ctime, err = u32(v); return },
+	"rtime":                            func(c *StatCounters, v string) (err error) { c.Rtime, err = u32(v); return },
+	"ttime":                            func(c *StatCounters, v string) (err error) { c.Ttime, err = u32(v); return },
+	"agent_status":                     func(c *StatCounters, v string) (err error) { c.AgentStatus = CheckStatus(v); return },
+	"agent_code":                       func(c *StatCounters, v string) (err error) { c.AgentCode, err = u32(v); return },
+	"agent_duration":                   func(c *StatCounters, v string) (err error) { c.AgentDuration, err = u64(v); return },
+	"check_desc":                       func(c *StatCounters, v string) (err error) { c.CheckDesc = v; return },
+	"agent_desc":                       func(c *StatCounters, v string) (err error) { c.AgentDesc = v; return },
+	"check_rise":                       func(c *StatCounters, v string) (err error) { c.CheckRise, err = u32(v); return },
+	"check_fall":                       func(c *StatCounters, v string) (err error) { c.CheckFall, err = u32(v); return },
+	"check_health":                     func(c *StatCounters, v string) (err error) { c.CheckHealth, err = u32(v); return },
+	"agent_rise":                       func(c *StatCounters, v string) (err error) { c.AgentRise, err = u32(v); return },
+	"agent_fall":                       func(c *StatCounters, v string) (err error) { c.AgentFall, err = u32(v); return },
+	"agent_health":                     func(c *StatCounters, v string) (err error) { c.AgentHealth, err = u32(v); return },
+	"addr":                             func(c *StatCounters, v string) (err error) { c.Addr = v; return },
+	"cookie":                           func(c *StatCounters, v string) (err error) { c.Cookie = v; return },
+	"mode":                             func(c *StatCounters, v string) (err error) { c.Mode = Mode(v); return },
+	"algo":                             func(c *StatCounters, v string) (err error) { c.Algo = Algo(v); return },
+	"conn_rate":                        func(c *StatCounters, v string) (err error) { c.ConnRate, err = u32(v); return },
+	"conn_rate_max":                    func(c *StatCounters, v string) (err error) { c.ConnRateMax, err = u32(v); return },
+	"conn_tot":                         func(c *StatCounters, v string) (err error) { c.ConnTot, err = u64(v); return },
+	"intercepted":                      func(c *StatCounters, v string) (err error) { c.Intercepted, err = u64(v); return },
+	"dcon":                             func(c *StatCounters, v string) (err error) { c.Dcon, err = u64(v); return },
+	"dses":                             func(c *StatCounters, v string) (err error) { c.Dses, err = u64(v); return },
+	"wrew":                             func(c *StatCounters, v string) (err error) { c.Wrew, err = u64(v); return },
+	"connect":                          func(c *StatCounters, v string) (err error) { c.Connect, err = u64(v); return },
+	"reuse":                            func(c *StatCounters, v string) (err error) { c.Reuse, err = u64(v); return },
+	"cache_lookups":                    func(c *StatCounters, v string) (err error) { c.CacheLookups, err = u64(v); return },
+	"cache_hits":                       func(c *StatCounters, v string) (err error) { c.CacheHits, err = u64(v); return },
+	"srv_icur":                         func(c *StatCounters, v string) (err error) { c.SrvIcur, err = u32(v); return },
+	"src_ilim":                         func(c *StatCounters, v string) (err error) { c.SrcIlim, err = u32(v); return },
+	"qtime_max":                        func(c *StatCounters, v string) (err error) { c.QtimeMax, err = u32(v); return },
+	"ctime_max":                        func(c *StatCounters, v string) (err error) { c.CtimeMax, err = u32(v); return },
+	"rtime_max":                        func(c *StatCounters, v string) (err error) { c.RtimeMax, err = u32(v); return },
+	"ttime_max":                        func(c *StatCounters, v string) (err error) { c.TtimeMax, err = u32(v); return },
+	"eint":                             func(c *StatCounters, v string) (err error) { c.Eint, err = u64(v); return },
+	"idle_conn_cur":                    func(c *StatCounters, v string) (err error) { c.IdleConnCur, err = u32(v); return },
+	"safe_conn_cur":                    func(c *StatCounters, v string) (err error) { c.SafeConnCur, err = u32(v); return },
+	"used_conn_cur":                    func(c *StatCounters, v string) (err error) { c.UsedConnCur, err = u32(v); return },
+	"need_conn_est":                    func(c *StatCounters, v string) (err error) { c.NeedConnEst, err = u32(v); return },
+	"uweight":                          func(c *StatCounters, v string) (err error) { c.Uweight, err = u32(v); return },
+	"agg_server_status":                func(c *StatCounters, v string) (err error) { c.AggServerStatus, err = u32(v); return },
+	"agg_server_check_status":          func(c *StatCounters, v string) (err error) { c.AggServerCheckStatus, err = u32(v); return },
+	"agg_check_status":                 func(c *StatCounters, v string) (err error) { c.AggCheckStatus, err = u32(v); return },
+	"ssl_sess":                         func(c *StatCounters, v string) (err error) { c.SSL.Sess, err = u64(v); return },
+	"ssl_reused_sess":                  func(c *StatCounters, v string) (err error) { c.SSL.ReusedSess, err = u64(v); return },
+	"ssl_failed_handshake":             func(c *StatCounters, v string) (err error) { c.SSL.FailedHandshake, err = u64(v); return },
+	"h2_headers_rcvd":                  func(c *StatCounters, v string) (err error) { c.H2.HeadersRcvd, err = u64(v); return },
+	"h2_data_rcvd":                     func(c *StatCounters, v string) (err error) { c.H2.DataRcvd, err = u64(v); return },
+	"h2_settings_rcvd":                 func(c *StatCounters, v string) (err error) { c.H2.SettingsRcvd, err = u64(v); return },
+	"h2_rst_stream_rcvd":               func(c *StatCounters, v string) (err error) { c.H2.RstStreamRcvd, err = u64(v); return },
+	"h2_goaway_rcvd":                   func(c *StatCounters, v string) (err error) { c.H2.GoawayRcvd, err = u64(v); return },
+	"h2_detected_conn_protocol_errors": func(c *StatCounters, v string) (err error) { c.H2.DetectedConnProtocolErrors, err = u64(v); return },
+	"h2_detected_strm_protocol_errors": func(c *StatCounters, v string) (err error) { c.H2.DetectedStrmProtocolErrors, err = u64(v); return },
+	"h2_rst_stream_resp":               func(c *StatCounters, v string) (err error) { c.H2.RstStreamResp, err = u64(v); return },
+	"h2_goaway_resp":                   func(c *StatCounters, v string) (err error) { c.H2.GoawayResp, err = u64(v); return },
+	"h2_open_connections":              func(c *StatCounters, v string) (err error) { c.H2.OpenConnections, err = u64(v); return },
+	"h2_backend_open_streams":          func(c *StatCounters, v string) (err error) { c.H2.BackendOpenStreams, err = u64(v); return },
+	"h2_total_connections":             func(c *StatCounters, v string) (err error) { c.H2.TotalConnections, err = u64(v); return },
+	"h2_backend_total_streams":         func(c *StatCounters, v string) (err error) { c.H2.BackendTotalStreams, err = u64(v); return },
+	"h1_open_connections":              func(c *StatCounters, v string) (err error) { c.H1.OpenConnections, err = u64(v); return },
+	"h1_open_streams":                  func(c *StatCounters, v string) (err error) { c.H1.OpenStreams, err = u64(v); return },
+	"h1_total_connections":             func(c *StatCounters, v string) (err error) { c.H1.TotalConnections, err = u64(v); return },
+	"h1_total_streams":                 func(c *StatCounters, v string) (err error) { c.H1.TotalStreams, err = u64(v); return },
+	"h1_bytes_in":                      func(c *StatCounters, v string) (err error) { c.H1.BytesIn, err = u64(v); return },
+	"h1_bytes_out":                     func(c *StatCounters, v string) (err error) { c.H1.BytesOut, err = u64(v); return },
+	"h1_spliced_bytes_in":              func(c *StatCounters, v string) (err error) { c.H1.SplicedBytesIn, err = u64(v); return },
+	"h1_spliced_bytes_out":             func(c *StatCounters, v string) (err error) { c.H1.SplicedBytesOut, err = u64(v); return },
 }
 
 // parse the response of the command show stat from the Runtime API
 // using the CSV format as defined http://docs.haproxy.org/2.6/management.html#9.1
+// the columns are mapped by the names in the header line so columns added, removed
+// or reordered between HA-Proxy versions are handled. Responses without a header
+// are mapped using the columns of HA-Proxy 2.6
+// the first value which can not be parsed is returned as a *ParseError
 func ParseShowStat(response []byte) ([]StatCounters, error) {
-	r := csv.NewReader(bytes.NewReader(response))
-	r.Comma = ','   // comma separated colums in the runtime API responses
-	r.Comment = '#' // lines with # is comments in the runtime API responses
+	return parseShowStat(response, false)
+}
 
-	all, err := r.ReadAll()
-	if err != nil {
-		return nil, err
-	}
+// parse the response of the command show stat like ParseShowStat but continue past lines
+// which can not be parsed. The lines parsed are returned together with ParseErrors for the
+// lines left out, the error is nil when all lines were parsed
+func ParseShowStatLenient(response []byte) ([]StatCounters, error) {
+	return parseShowStat(response, true)
+}
+
+func parseShowStat(response []byte, lenient bool) ([]StatCounters, error) {
+	header, body, offset := parseHeader(response)
+
+	r := csv.NewReader(bytes.NewReader(body))
+	r.Comma = ','          // comma separated colums in the runtime API responses
+	r.Comment = '#'        // lines with # is comments in the runtime API responses
+	r.FieldsPerRecord = -1 // rows are mapped by the header so the number of columns is not checked
+
+	var stats []StatCounters
+	var errs ParseErrors
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			perr := &ParseError{Err: err}
+			if cerr, ok := err.(*csv.ParseError); ok {
+				perr.Line = cerr.Line + offset
+			}
+			if !lenient {
+				return nil, perr
+			}
+			errs = append(errs, perr)
+			continue
+		}
 
-	stats := make([]StatCounters, 0, len(all))
-	for _, row := range all {
-		stats = append(stats, elementsToStatCounters(row))
+		line, _ := r.FieldPos(0)
+		c, err := headerElementsToStatCounters(header, row, line+offset)
+		if err != nil {
+			if !lenient {
+				return nil, err
+			}
+			errs = append(errs, err.(*ParseError))
+			continue
+		}
+		stats = append(stats, c)
 	}
 
+	if len(errs) > 0 {
+		return stats, errs
+	}
 	return stats, nil
 }
 
-// map the elements from a response line to the StatCounters struct. If the elements can not be mapped it will panic.
-func elementsToStatCounters(elements []string) StatCounters {
-	return StatCounters{
-		PxName:        elements[0],
-		SvName:        elements[1],
-		Qcur:          u32(elements[2]),
-		Qmax:          u32(elements[3]),
-		Scur:          u32(elements[4]),
-		Smax:          u32(elements[5]),
-		Slim:          u32(elements[6]),
-		Stot:          u64(elements[7]),
-		Bin:           u64(elements[8]),
-		Bout:          u64(elements[9]),
-		Dreg:          u64(elements[10]),
-		Dresp:         u64(elements[11]),
-		Ereg:          u64(elements[12]),
-		Econ:          u64(elements[13]),
-		Eresp:         u64(elements[14]),
-		Wretr:         u64(elements[15]),
-		Wredis:        u64(elements[16]),
-		Status:        elements[17],
-		Weight:        u32(elements[18]),
-		Act:           u32(elements[19]),
-		Bck:           u32(elements[20]),
-		ChkFail:       u64(elements[21]),
-		ChkDown:       u64(elements[22]),
-		LastChg:       u32(elements[23]),
-		Downtime:      u32(elements[24]),
-		Qlimit:        u64(elements[25]),
-		Pid:           u32(elements[26]),
-		Iid:           u32(elements[27]),
-		Sid:           u32(elements[28]),
-		Throttle:      u64(elements[29]),
-		Lbtot:         u64(elements[30]),
-		Tracked:       u32(elements[31]),
-		Type:          u32(elements[32]),
-		Rate:          u32(elements[33]),
-		RateLim:       u32(elements[34]),
-		RateMax:       u32(elements[35]),
-		CheckStatus:   elements[36],
-		CheckCode:     u32(elements[37]),
-		CheckDuration: u64(elements[38]),
-		Hrsp1xx:       u64(elements[39]),
-		Hrsp2xx:       u64(elements[40]),
-		Hrsp3xx:       u64(elements[41]),
-		Hrsp4xx:       u64(elements[42]),
-		Hrsp5xx:       u64(elements[43]),
-		HrspOther:     u64(elements[44]),
-		HanaFail:      u64(elements[45]),
-		ReqRate:       u32(elements[46]),
-		ReqRateMax:    u32(elements[47]),
-		ReqTot:        u64(elements[48]),
-		CliAbrt:       u64(elements[49]),
-		SrvAbrt:       u64(elements[50]),
-		CompIn:        u64(elements[51]),
-		CompOut:       u64(elements[52]),
-		CompByp:       u64(elements[53]),
-		CompRsp:       u64(elements[54]),
-		LastSess:      atoi(elements[55]),
-		LastChk:       elements[56],
-		LastAgt:       elements[57],
-		Qtime:         u32(elements[58]),
-		Ctime:         u32(elements[59]),
-		Rtime:         u32(elements[60]),
-		Ttime:         u32(elements[61]),
-		AgentStatus:   elements[62],
-		AgentCode:     u32(elements[63]),
-		AgentDuration: u64(elements[64]),
-		CheckDesc:     elements[65],
-		AgentDesc:     elements[66],
-		CheckRise:     u32(elements[67]),
-		CheckFall:     u32(elements[68]),
-		CheckHealth:   u32(elements[69]),
-		AgentRise:     u32(elements[70]),
-		AgentFall:     u32(elements[71]),
-		AgentHealth:   u32(elements[72]),
-		Addr:          elements[73],
-		Cookie:        elements[74],
-		Mode:          elements[75],
-		Algo:          elements[76],
-		ConnRate:      u32(elements[77]),
-		ConnRateMax:   u32(elements[78]),
-		ConnTot:       u64(elements[79]),
-		Intercepted:   u64(elements[80]),
-		Dcon:          u64(elements[81]),
-		Dses:          u64(elements[82]),
-		Wrew:          u64(elements[83]),
-		Connect:       u64(elements[84]),
-		Reuse:         u64(elements[85]),
-		CacheLookups:  u64(elements[86]),
-		CacheHits:     u64(elements[87]),
-		SrvIcur:       u32(elements[88]),
-		SrcIlim:       u32(elements[89]),
-		QtimeMax:      u32(elements[90]),
-		CtimeMax:      u32(elements[91]),
-		RtimeMax:      u32(elements[92]),
-		TtimeMax:      u32(elements[93]),
-		Eint:          u64(elements[94]),
-		IdleConnCur:   u32(elements[95]),
-		SafeConnCur:   u32(elements[96]),
-		UsedConnCur:   u32(elements[97]),
-		NeedConnEst:   u32(elements[98]),
-		Uweight:       u32(elements[99]),
+// split the header line "# pxname,svname,..." from the response returning the column names,
+// the remaining response and the number of lines removed. The default header is returned
+// when the header is missing
+func parseHeader(response []byte) ([]string, []byte, int) {
+	if !bytes.HasPrefix(response, []byte("# ")) {
+		return defaultHeader, response, 0
 	}
+	line, rest, _ := bytes.Cut(response, []byte("\n"))
+	names := strings.TrimSuffix(strings.TrimSpace(string(line[2:])), ",")
+	return strings.Split(names, ","), rest, 1
 }
-func atoi(s string) int {
-	if len(s) == 0 {
-		return 0
-	}
-	x, err := strconv.Atoi(s)
-	if err != nil {
-		panic(err)
+
+// map the elements from a response line to the StatCounters struct by the column names in the header
+// columns without a field in StatCounters are kept in Extra, and fields for columns missing from
+// the header or the line are left as zero values. A value which can not be parsed is returned
+// as a *ParseError for the line
+func headerElementsToStatCounters(header, elements []string, line int) (StatCounters, error) {
+	var c StatCounters
+	for i, name := range header {
+		if i >= len(elements) {
+			break
+		}
+		if set, ok := columns[name]; ok {
+			if err := set(&c, elements[i]); err != nil {
+				return StatCounters{}, &ParseError{Line: line, Column: name, Value: elements[i], Err: err}
+			}
+			continue
+		}
+		// the unnamed column of HA-Proxy 2.6 is a placeholder
+		if name == "" || name == "-" {
+			continue
+		}
+		if c.Extra == nil {
+			c.Extra = make(map[string]string)
+		}
+		c.Extra[name] = elements[i]
 	}
-	return x
+	return c, nil
 }
 
-func u64(s string) uint64 {
+func atoi(s string) (int, error) {
 	if len(s) == 0 {
-		return 0
-	}
-	x, err := strconv.ParseUint(s, 10, 64)
-	if err != nil {
-		panic(err)
+		return 0, nil
 	}
-	return x
+	return strconv.Atoi(s)
 }
 
-func u32(s string) uint32 {
+func u64(s string) (uint64, error) {
 	if len(s) == 0 {
-		return 0
-	}
-	x, err := strconv.ParseUint(s, 10, 32)
-	if err != nil {
-		panic(err)
+		return 0, nil
 	}
-	return uint32(x)
+	return strconv.ParseUint(s, 10, 64)
 }
 
-func atob(s string) bool {
-	return atoi(s) != 0
+func u32(s string) (uint32, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	x, err := strconv.ParseUint(s, 10, 32)
+	return uint32(x), err
 }
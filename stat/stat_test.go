@@ -1,22 +1,21 @@
 package stat
 
 import (
-	"bytes"
-	"encoding/csv"
+	"errors"
 	"testing"
 )
 
-func TestElementsToStatCounters(t *testing.T) {
+// every column of a line without the header is mapped using the columns of HA-Proxy 2.6
+func TestParseShowStatColumns(t *testing.T) {
 	line := "indexws,iws01,0,0,0,1,,8592,1678803,9633529,,0,,4,0,14,0,UP,1,1,0,33,16,10916,15450,,1,4,1,,8578,,2,0,,1,L7OK,200,47,0,8554,0,0,18,0,,,,8572,0,1,,,,,4,,,0,23,31,4590,,,,Layer7 check passed,,2,3,4,,,,172.24.21.40:8080,,http,,,,,,,,0,7992,600,,,7953,,0,175,172,5272,0,7858,95,4294959343,4294959345,1,,,,-,0,0,0,,,,,,,,,,,,,,,,,,,,,,"
-	r := csv.NewReader(bytes.NewReader([]byte(line)))
-	r.Comma = ','   // white space separated colums in the runtime API responses
-	r.Comment = '#' // lines with # is comments in the runtime API responses
-
-	lines, err := r.ReadAll()
+	stats, err := ParseShowStat([]byte(line + "\n"))
 	if err != nil {
-		t.Fatalf("unable to read CSV : %v", err)
+		t.Fatalf("unable to parse : %v", err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected 1 line got %d", len(stats))
 	}
-	c := elementsToStatCounters(lines[0])
+	c := stats[0]
 
 	if c.PxName != "indexws" {
 		t.Fatalf("PxName not indexws")
@@ -322,3 +321,140 @@ func TestElementsToStatCounters(t *testing.T) {
 	// blank,blank,blank,-,0,0,0,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank,blank
 
 }
+
+func TestParseShowStatByHeader(t *testing.T) {
+	// columns reordered, uweight removed and a column unknown to StatCounters added
+	response := "# svname,pxname,scur,new_counter,status,\n" +
+		"iws01,indexws,3,42,UP,\n" +
+		"BACKEND,indexws,3,,UP,\n" +
+		"\n"
+
+	stats, err := ParseShowStat([]byte(response))
+	if err != nil {
+		t.Fatalf("unable to parse show stat: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 rows got %d", len(stats))
+	}
+
+	c := stats[0]
+	if c.PxName != "indexws" || c.SvName != "iws01" || c.Scur != 3 || c.Status != "UP" {
+		t.Fatalf("unexpected counters: %+v", c)
+	}
+	if c.Uweight != 0 {
+		t.Fatalf("Uweight not 0")
+	}
+	if c.Extra["new_counter"] != "42" {
+		t.Fatalf("new_counter not in Extra: %v", c.Extra)
+	}
+	if stats[1].Extra["new_counter"] != "" {
+		t.Fatalf("new_counter not empty for backend")
+	}
+}
+
+func TestParseShowStatShortRows(t *testing.T) {
+	response := "# pxname,svname,qcur,qmax,scur,smax,\n" +
+		"indexws,iws01,0,0,5\n" +
+		"\n"
+
+	stats, err := ParseShowStat([]byte(response))
+	if err != nil {
+		t.Fatalf("unable to parse show stat: %v", err)
+	}
+	if stats[0].Scur != 5 || stats[0].Smax != 0 {
+		t.Fatalf("unexpected counters: %+v", stats[0])
+	}
+}
+
+func TestParseShowStatWithoutHeader(t *testing.T) {
+	line := "indexws,iws01,0,0,0,1,,8592,1678803,9633529,,0,,4,0,14,0,UP,1,1,0,33,16,10916,15450,,1,4,1,,8578,,2,0,,1,L7OK,200,47,0,8554,0,0,18,0,,,,8572,0,1,,,,,4,,,0,23,31,4590,,,,Layer7 check passed,,2,3,4,,,,172.24.21.40:8080,,http,,,,,,,,0,7992,600,,,7953,,0,175,172,5272,0,7858,95,4294959343,4294959345,1,,,,-,0,0,0,,,,,,,,,,,,,,,,,,,,,,\n"
+
+	stats, err := ParseShowStat([]byte(line))
+	if err != nil {
+		t.Fatalf("unable to parse show stat: %v", err)
+	}
+	if stats[0].Stot != 8592 || stats[0].Uweight != 1 {
+		t.Fatalf("unexpected counters: %+v", stats[0])
+	}
+	if stats[0].Extra != nil {
+		t.Fatalf("all HA-Proxy 2.6 columns not mapped: %v", stats[0].Extra)
+	}
+}
+
+func TestParseShowStatError(t *testing.T) {
+	response := "# pxname,svname,scur,\n" +
+		"indexws,iws01,3,\n" +
+		"indexws,iws02,many,\n" +
+		"\n"
+
+	_, err := ParseShowStat([]byte(response))
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected ParseError got: %v", err)
+	}
+	if perr.Line != 3 || perr.Column != "scur" || perr.Value != "many" {
+		t.Fatalf("unexpected parse error: %+v", perr)
+	}
+}
+
+func TestParseShowStatLenient(t *testing.T) {
+	response := "# pxname,svname,scur,\n" +
+		"indexws,iws01,3,\n" +
+		"indexws,iws02,many,\n" +
+		"indexws,iws03,-1,\n" +
+		"indexws,BACKEND,3,\n" +
+		"\n"
+
+	stats, err := ParseShowStatLenient([]byte(response))
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expected 2 parse errors got: %v", err)
+	}
+	if errs[0].Line != 3 || errs[1].Line != 4 {
+		t.Fatalf("unexpected lines of parse errors: %v", errs)
+	}
+	if len(stats) != 2 || stats[0].SvName != "iws01" || stats[1].SvName != "BACKEND" {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestParseShowStatEmpty(t *testing.T) {
+	stats, err := ParseShowStat([]byte{})
+	if err != nil {
+		t.Fatalf("unable to parse empty response: %v", err)
+	}
+	if len(stats) != 0 {
+		t.Fatalf("expected no stats got %d", len(stats))
+	}
+}
+
+func TestParseShowStatModuleCounters(t *testing.T) {
+	response := "# pxname,svname,agg_server_status,agg_check_status,ssl_sess,ssl_reused_sess,ssl_failed_handshake,h2_detected_conn_protocol_errors,h2_open_connections,h1_total_connections,h1_bytes_out,quic_packets_rcvd,\n" +
+		"https-in,FRONTEND,,,19,44,3,2,1,628,136539442,7,\n" +
+		"indexws,BACKEND,1,4,,,,,,,,,\n" +
+		"\n"
+
+	stats, err := ParseShowStat([]byte(response))
+	if err != nil {
+		t.Fatalf("unable to parse show stat: %v", err)
+	}
+
+	f := stats[0]
+	if f.SSL.Sess != 19 || f.SSL.ReusedSess != 44 || f.SSL.FailedHandshake != 3 {
+		t.Fatalf("unexpected SSL counters: %+v", f.SSL)
+	}
+	if f.H2.DetectedConnProtocolErrors != 2 || f.H2.OpenConnections != 1 {
+		t.Fatalf("unexpected H2 counters: %+v", f.H2)
+	}
+	if f.H1.TotalConnections != 628 || f.H1.BytesOut != 136539442 {
+		t.Fatalf("unexpected H1 counters: %+v", f.H1)
+	}
+	if f.Extra["quic_packets_rcvd"] != "7" {
+		t.Fatalf("quic_packets_rcvd not in Extra: %v", f.Extra)
+	}
+
+	b := stats[1]
+	if b.AggServerStatus != 1 || b.AggCheckStatus != 4 {
+		t.Fatalf("unexpected aggregated status: %+v", b)
+	}
+}
@@ -0,0 +1,293 @@
+package stat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ObjectType is the type of the object a row of stat counters is reported for
+// as reported in the type column (32) of show stat
+type ObjectType uint32
+
+const (
+	ObjectTypeFrontend ObjectType = 0
+	ObjectTypeBackend  ObjectType = 1
+	ObjectTypeServer   ObjectType = 2
+	ObjectTypeListener ObjectType = 3
+)
+
+func (t ObjectType) String() string {
+	switch t {
+	case ObjectTypeFrontend:
+		return "frontend"
+	case ObjectTypeBackend:
+		return "backend"
+	case ObjectTypeServer:
+		return "server"
+	case ObjectTypeListener:
+		return "listener"
+	default:
+		return "ObjectType(" + strconv.FormatUint(uint64(t), 10) + ")"
+	}
+}
+
+// Status of a frontend, backend, server or listener as reported in the status column (17).
+// Servers in a transition between states are reported with the progress of the health
+// checks e.g. "UP 1/3" for a server going down after the first failed check of three, and
+// some states are followed by the reason e.g. "MAINT (via backend/server)" for a server
+// tracking a server in maintenance. Base removes the progress and reason.
+// StatCounters keeps the status as reported so statuses of newer HA-Proxy versions
+// are not lost, Valid and ParseStatus check it is one of the known statuses
+type Status string
+
+const (
+	StatusUp      Status = "UP"
+	StatusDown    Status = "DOWN"
+	StatusNoLB    Status = "NOLB"  // the server is up but not taking new connections from the load balancing
+	StatusMaint   Status = "MAINT" // the server is in maintenance
+	StatusDrain   Status = "DRAIN" // the server is up but draining
+	StatusNoCheck Status = "no check"
+	StatusOpen    Status = "OPEN" // frontends and listeners accepting connections
+	StatusFull    Status = "FULL" // frontends and listeners at the maximum number of connections
+	StatusStop    Status = "STOP" // stopped frontends and listeners
+	StatusWaiting Status = "WAITING"
+)
+
+// parse a status as reported in the status column, an unknown status is returned with an error
+func ParseStatus(s string) (Status, error) {
+	status := Status(s)
+	if !status.Valid() {
+		return status, fmt.Errorf("unknown status: %q", s)
+	}
+	return status, nil
+}
+
+// reports if the status is one of the known statuses, with or without the progress and reason
+func (s Status) Valid() bool {
+	switch s.Base() {
+	case StatusUp, StatusDown, StatusNoLB, StatusMaint, StatusDrain, StatusNoCheck,
+		StatusOpen, StatusFull, StatusStop, StatusWaiting:
+	default:
+		return false
+	}
+	if _, progress, found := strings.Cut(string(s), " "); found && s != StatusNoCheck && !strings.HasPrefix(progress, "(") {
+		_, _, ok := s.Transition()
+		return ok
+	}
+	return true
+}
+
+// the state without the progress of a transition and the reason
+func (s Status) Base() Status {
+	base := string(s)
+	if i := strings.IndexAny(base, " ("); i >= 0 && s != StatusNoCheck {
+		base = base[:i]
+	}
+	return Status(base)
+}
+
+// the progress of a transition between states as the number of checks made and the
+// number of checks required, ok is false when the status is not in a transition
+func (s Status) Transition() (checks, required int, ok bool) {
+	_, progress, found := strings.Cut(string(s), " ")
+	if !found {
+		return 0, 0, false
+	}
+	c, r, found := strings.Cut(progress, "/")
+	if !found {
+		return 0, 0, false
+	}
+	checks, err := strconv.Atoi(c)
+	if err != nil {
+		return 0, 0, false
+	}
+	required, err = strconv.Atoi(r)
+	if err != nil {
+		return 0, 0, false
+	}
+	return checks, required, true
+}
+
+// reports if the server is running and reported as up by the health checks, including
+// servers going down and servers without checks, or if a frontend or listener is open.
+// A server draining or not taking new connections is still up
+func (s Status) IsUp() bool {
+	switch s.Base() {
+	case StatusUp, StatusNoLB, StatusDrain, StatusNoCheck, StatusOpen, StatusFull:
+		return true
+	default:
+		return false
+	}
+}
+
+// reports if the server is in maintenance, set on the server itself, inherited from
+// a tracked server or because of a failing resolution of the address
+func (s Status) IsInMaintenance() bool {
+	return s.Base() == StatusMaint
+}
+
+// reports if the server is draining
+func (s Status) IsDraining() bool {
+	return s.Base() == StatusDrain
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// CheckStatus is the status of the last health or agent check. The status of a check
+// currently running is prefixed with "* ", e.g. "* L7OK". Code removes the prefix.
+// The status is empty for servers without the check
+type CheckStatus string
+
+const (
+	CheckStatusUnknown         CheckStatus = "UNK"      // unknown
+	CheckStatusInitializing    CheckStatus = "INI"      // initializing
+	CheckStatusSocketError     CheckStatus = "SOCKERR"  // socket error
+	CheckStatusL4OK            CheckStatus = "L4OK"     // check passed on layer 4, no upper layers testing enabled
+	CheckStatusL4Timeout       CheckStatus = "L4TOUT"   // layer 1-4 timeout
+	CheckStatusL4ConnError     CheckStatus = "L4CON"    // layer 1-4 connection problem, for example "Connection refused"
+	CheckStatusL6OK            CheckStatus = "L6OK"     // check passed on layer 6
+	CheckStatusL6Timeout       CheckStatus = "L6TOUT"   // layer 6 (SSL) timeout
+	CheckStatusL6Response      CheckStatus = "L6RSP"    // layer 6 invalid response - protocol error
+	CheckStatusL7OK            CheckStatus = "L7OK"     // check passed on layer 7
+	CheckStatusL7OKConditional CheckStatus = "L7OKC"    // check conditionally passed on layer 7, for example 404 with disable-on-404
+	CheckStatusL7Timeout       CheckStatus = "L7TOUT"   // layer 7 (HTTP/SMTP) timeout
+	CheckStatusL7Response      CheckStatus = "L7RSP"    // layer 7 invalid response - protocol error
+	CheckStatusL7Status        CheckStatus = "L7STS"    // layer 7 response error, for example HTTP 5xx
+	CheckStatusProcessError    CheckStatus = "PROCERR"  // external process check error
+	CheckStatusProcessTimeout  CheckStatus = "PROCTOUT" // external process check timeout
+	CheckStatusProcessOK       CheckStatus = "PROCOK"   // external check passed
+	CheckStatusHealthAnalyze   CheckStatus = "HANA"     // health analyze detected enough consecutive errors
+)
+
+// parse the status of a check as reported in the check_status and agent_status columns,
+// an unknown status is returned with an error
+func ParseCheckStatus(s string) (CheckStatus, error) {
+	status := CheckStatus(s)
+	if !status.Valid() {
+		return status, fmt.Errorf("unknown check status: %q", s)
+	}
+	return status, nil
+}
+
+// reports if the status is one of the known check statuses, with or without the prefix
+// of a running check. The empty status of a server without the check is not valid
+func (s CheckStatus) Valid() bool {
+	switch s.Code() {
+	case CheckStatusUnknown, CheckStatusInitializing, CheckStatusSocketError, CheckStatusL4OK,
+		CheckStatusL4Timeout, CheckStatusL4ConnError, CheckStatusL6OK, CheckStatusL6Timeout,
+		CheckStatusL6Response, CheckStatusL7OK, CheckStatusL7OKConditional, CheckStatusL7Timeout,
+		CheckStatusL7Response, CheckStatusL7Status, CheckStatusProcessError, CheckStatusProcessTimeout,
+		CheckStatusProcessOK, CheckStatusHealthAnalyze:
+		return true
+	default:
+		return false
+	}
+}
+
+// prefix of the status of the last check while a check is running
+const checkStatusInProgressPrefix = "* "
+
+// the status of the last check without the prefix of a running check
+func (s CheckStatus) Code() CheckStatus {
+	return CheckStatus(strings.TrimPrefix(string(s), checkStatusInProgressPrefix))
+}
+
+// reports if a check is currently running
+func (s CheckStatus) InProgress() bool {
+	return strings.HasPrefix(string(s), checkStatusInProgressPrefix)
+}
+
+// reports if the last check passed
+func (s CheckStatus) IsOK() bool {
+	switch s.Code() {
+	case CheckStatusL4OK, CheckStatusL6OK, CheckStatusL7OK, CheckStatusL7OKConditional, CheckStatusProcessOK:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s CheckStatus) String() string {
+	return string(s)
+}
+
+// Mode of a proxy
+type Mode string
+
+const (
+	ModeTCP     Mode = "tcp"
+	ModeHTTP    Mode = "http"
+	ModeHealth  Mode = "health"
+	ModeCLI     Mode = "cli"
+	ModeSyslog  Mode = "syslog"
+	ModePeers   Mode = "peers"
+	ModeUnknown Mode = "unknown"
+)
+
+// parse the mode of a proxy as reported in the mode column, an unknown mode is returned with an error
+func ParseMode(s string) (Mode, error) {
+	mode := Mode(s)
+	if !mode.Valid() {
+		return mode, fmt.Errorf("unknown mode: %q", s)
+	}
+	return mode, nil
+}
+
+// reports if the mode is one of the known modes, including the unknown mode reported by HA-Proxy
+func (m Mode) Valid() bool {
+	switch m {
+	case ModeTCP, ModeHTTP, ModeHealth, ModeCLI, ModeSyslog, ModePeers, ModeUnknown:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m Mode) String() string {
+	return string(m)
+}
+
+// Algo is the load balancing algorithm of a backend, empty for other proxies
+type Algo string
+
+const (
+	AlgoRoundRobin Algo = "roundrobin"
+	AlgoStaticRR   Algo = "static-rr"
+	AlgoLeastConn  Algo = "leastconn"
+	AlgoFirst      Algo = "first"
+	AlgoSource     Algo = "source"
+	AlgoURI        Algo = "uri"
+	AlgoURLParam   Algo = "url_param"
+	AlgoHdr        Algo = "hdr"
+	AlgoRandom     Algo = "random"
+	AlgoRDPCookie  Algo = "rdp-cookie"
+	AlgoHash       Algo = "hash"
+)
+
+// parse the load balancing algorithm as reported in the algo column, an unknown algorithm
+// is returned with an error
+func ParseAlgo(s string) (Algo, error) {
+	algo := Algo(s)
+	if !algo.Valid() {
+		return algo, fmt.Errorf("unknown load balancing algorithm: %q", s)
+	}
+	return algo, nil
+}
+
+// reports if the algorithm is one of the known load balancing algorithms
+func (a Algo) Valid() bool {
+	switch a {
+	case AlgoRoundRobin, AlgoStaticRR, AlgoLeastConn, AlgoFirst, AlgoSource, AlgoURI,
+		AlgoURLParam, AlgoHdr, AlgoRandom, AlgoRDPCookie, AlgoHash:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a Algo) String() string {
+	return string(a)
+}
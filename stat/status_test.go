@@ -0,0 +1,115 @@
+package stat
+
+import "testing"
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		status      Status
+		base        Status
+		up          bool
+		maintenance bool
+		draining    bool
+	}{
+		{"UP", StatusUp, true, false, false},
+		{"UP 1/3", StatusUp, true, false, false},
+		{"DOWN", StatusDown, false, false, false},
+		{"DOWN 1/2", StatusDown, false, false, false},
+		{"DOWN (agent)", StatusDown, false, false, false},
+		{"NOLB", StatusNoLB, true, false, false},
+		{"DRAIN", StatusDrain, true, false, true},
+		{"DRAIN (agent)", StatusDrain, true, false, true},
+		{"MAINT", StatusMaint, false, true, false},
+		{"MAINT (via indexws/iws01)", StatusMaint, false, true, false},
+		{"MAINT (resolution)", StatusMaint, false, true, false},
+		{"no check", StatusNoCheck, true, false, false},
+		{"OPEN", StatusOpen, true, false, false},
+		{"STOP", StatusStop, false, false, false},
+	}
+	for _, test := range tests {
+		if base := test.status.Base(); base != test.base {
+			t.Fatalf("%q: expected base %q got %q", test.status, test.base, base)
+		}
+		if test.status.IsUp() != test.up {
+			t.Fatalf("%q: expected up %t", test.status, test.up)
+		}
+		if test.status.IsInMaintenance() != test.maintenance {
+			t.Fatalf("%q: expected maintenance %t", test.status, test.maintenance)
+		}
+		if test.status.IsDraining() != test.draining {
+			t.Fatalf("%q: expected draining %t", test.status, test.draining)
+		}
+	}
+}
+
+func TestStatusTransition(t *testing.T) {
+	checks, required, ok := Status("UP 1/3").Transition()
+	if !ok || checks != 1 || required != 3 {
+		t.Fatalf("unexpected transition %d/%d %t", checks, required, ok)
+	}
+	for _, s := range []Status{"UP", "no check", "MAINT (via indexws/iws01)"} {
+		if _, _, ok := s.Transition(); ok {
+			t.Fatalf("%q: unexpected transition", s)
+		}
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	s := CheckStatus("* L7OK")
+	if !s.InProgress() || s.Code() != CheckStatusL7OK || !s.IsOK() {
+		t.Fatalf("unexpected check status %q", s)
+	}
+	for _, s := range []CheckStatus{"L7STS", "SOCKERR", "L4TOUT", "* L4CON", ""} {
+		if s.IsOK() {
+			t.Fatalf("%q: unexpected ok", s)
+		}
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	for _, s := range []string{"UP", "UP 1/3", "DOWN (agent)", "MAINT (via indexws/iws01)", "no check", "OPEN", "WAITING"} {
+		if status, err := ParseStatus(s); err != nil || status != Status(s) {
+			t.Fatalf("%q: unexpected status %q: %v", s, status, err)
+		}
+	}
+	for _, s := range []string{"", "up", "BROKEN", "UP x/3", "no"} {
+		if _, err := ParseStatus(s); err == nil {
+			t.Fatalf("%q: expected error", s)
+		}
+	}
+}
+
+func TestParseCheckStatus(t *testing.T) {
+	for _, s := range []string{"L7OK", "* L4CON", "SOCKERR", "HANA", "PROCOK"} {
+		if status, err := ParseCheckStatus(s); err != nil || status != CheckStatus(s) {
+			t.Fatalf("%q: unexpected check status %q: %v", s, status, err)
+		}
+	}
+	for _, s := range []string{"", "l7ok", "L8OK", "* "} {
+		if _, err := ParseCheckStatus(s); err == nil {
+			t.Fatalf("%q: expected error", s)
+		}
+	}
+}
+
+func TestParseModeAndAlgo(t *testing.T) {
+	if mode, err := ParseMode("http"); err != nil || mode != ModeHTTP {
+		t.Fatalf("unexpected mode %q: %v", mode, err)
+	}
+	if _, err := ParseMode("smtp"); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+	if algo, err := ParseAlgo("static-rr"); err != nil || algo != AlgoStaticRR {
+		t.Fatalf("unexpected algo %q: %v", algo, err)
+	}
+	for _, s := range []string{"", "round-robin"} {
+		if _, err := ParseAlgo(s); err == nil {
+			t.Fatalf("%q: expected error", s)
+		}
+	}
+}
+
+func TestObjectTypeString(t *testing.T) {
+	if ObjectTypeServer.String() != "server" || ObjectType(7).String() != "ObjectType(7)" {
+		t.Fatalf("unexpected object type names")
+	}
+}
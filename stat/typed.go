@@ -0,0 +1,192 @@
+package stat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Origin of a typed field value
+type Origin byte
+
+const (
+	OriginMetric  Origin = 'M' // the value is a metric
+	OriginStatus  Origin = 'S' // the value is a status
+	OriginKey     Origin = 'K' // the value is used as an identifier key
+	OriginConfig  Origin = 'C' // the value comes from the configuration
+	OriginProduct Origin = 'P' // the value describes the product
+)
+
+// Nature of a typed field value
+type Nature byte
+
+const (
+	NatureAge      Nature = 'A' // the value is an age, in seconds since an event
+	NatureAvg      Nature = 'a' // the value is an average
+	NatureCounter  Nature = 'C' // the value is a cumulative counter
+	NatureDuration Nature = 'D' // the value is a duration
+	NatureGauge    Nature = 'G' // the value is a gauge
+	NatureLimit    Nature = 'L' // the value is a limit
+	NatureMax      Nature = 'M' // the value is a maximum
+	NatureName     Nature = 'N' // the value is a name
+	NatureOutput   Nature = 'O' // the value is a free text output
+	NatureRate     Nature = 'R' // the value is an event rate
+	NatureTime     Nature = 'T' // the value is a date or time
+)
+
+// Scope of a typed field value
+type Scope byte
+
+const (
+	ScopeGlobal  Scope = 'G' // the value is global to the whole process group
+	ScopeProcess Scope = 'P' // the value is local to the process
+	ScopeService Scope = 'S' // the value is local to the service
+	ScopeSystem  Scope = 's' // the value is shared with the whole system
+)
+
+// ValueType of a typed field value
+type ValueType string
+
+const (
+	ValueTypeS32 ValueType = "s32"
+	ValueTypeS64 ValueType = "s64"
+	ValueTypeU32 ValueType = "u32"
+	ValueTypeU64 ValueType = "u64"
+	ValueTypeFlt ValueType = "flt"
+	ValueTypeStr ValueType = "str"
+)
+
+// TypedField is a field of the show stat typed output, e.g.
+// F.2.0.4.scur.1:MGP:u32:2:"Number of current sessions on the frontend, backend or server"
+// Reference: http://docs.haproxy.org/2.6/management.html#9.2
+type TypedField struct {
+	ObjectType  ObjectType // F (frontend), B (backend), L (listener) or S (server)
+	ProxyID     int        // unique proxy id (iid)
+	ObjectID    int        // id of the server or listener within the proxy, 0 for frontends and backends
+	Position    int        // position of the field in the show stat CSV output
+	Name        string     // name of the field as in the show stat CSV header
+	Process     int        // relative process number starting at 1
+	Origin      Origin
+	Nature      Nature
+	Scope       Scope
+	ValueType   ValueType
+	Value       string
+	Description string // only present when requested with show stat typed desc
+}
+
+// object types by the letter used in the typed output
+var typedObjectTypes = map[string]ObjectType{
+	"F": ObjectTypeFrontend,
+	"B": ObjectTypeBackend,
+	"S": ObjectTypeServer,
+	"L": ObjectTypeListener,
+}
+
+// parse the response of the command show stat typed [desc] from the Runtime API
+// using the typed format as defined in http://docs.haproxy.org/2.6/management.html#9.2
+// a line which can not be parsed is returned as a *ParseError
+func ParseShowStatTyped(response []byte) ([]TypedField, error) {
+	var fields []TypedField
+	scanner := bufio.NewScanner(bytes.NewReader(response))
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		f, err := parseTypedField(text)
+		if err != nil {
+			return nil, &ParseError{Line: line, Value: text, Err: err}
+		}
+		fields = append(fields, f)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// parse a single line of typed output
+func parseTypedField(line string) (TypedField, error) {
+	parts := strings.SplitN(line, ":", 4)
+	if len(parts) != 4 {
+		return TypedField{}, fmt.Errorf("expected position, tags, type and value separated by colons")
+	}
+
+	var f TypedField
+	position := strings.Split(parts[0], ".")
+	if len(position) != 6 {
+		return TypedField{}, fmt.Errorf("expected object type, proxy id, object id, position, name and process in %q", parts[0])
+	}
+	objectType, ok := typedObjectTypes[position[0]]
+	if !ok {
+		return TypedField{}, fmt.Errorf("unknown object type %q", position[0])
+	}
+	f.ObjectType = objectType
+	f.Name = position[4]
+	for i, dst := range map[int]*int{1: &f.ProxyID, 2: &f.ObjectID, 3: &f.Position, 5: &f.Process} {
+		x, err := strconv.Atoi(position[i])
+		if err != nil {
+			return TypedField{}, err
+		}
+		*dst = x
+	}
+
+	tags := parts[1]
+	if len(tags) != 3 {
+		return TypedField{}, fmt.Errorf("expected origin, nature and scope tags got %q", tags)
+	}
+	f.Origin = Origin(tags[0])
+	f.Nature = Nature(tags[1])
+	f.Scope = Scope(tags[2])
+	f.ValueType = ValueType(parts[2])
+
+	// the description is quoted and follows the value which may itself contain colons
+	value := parts[3]
+	if i := strings.Index(value, `:"`); i >= 0 && strings.HasSuffix(value, `"`) {
+		f.Description = value[i+2 : len(value)-1]
+		value = value[:i]
+	}
+	f.Value = value
+	return f, nil
+}
+
+// identity of the object a typed field belongs to
+type typedObject struct {
+	objectType ObjectType
+	proxyID    int
+	objectID   int
+	process    int
+}
+
+// convert typed fields into StatCounters with one StatCounters for each object in the order
+// the objects appear. Fields without a field in StatCounters are kept in Extra and a value
+// which can not be parsed is returned as a *ParseError without a line number
+func TypedToStatCounters(fields []TypedField) ([]StatCounters, error) {
+	var stats []StatCounters
+	index := make(map[typedObject]int)
+	for _, f := range fields {
+		key := typedObject{f.ObjectType, f.ProxyID, f.ObjectID, f.Process}
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, StatCounters{})
+		}
+
+		c := &stats[i]
+		if set, ok := columns[f.Name]; ok {
+			if err := set(c, f.Value); err != nil {
+				return nil, &ParseError{Column: f.Name, Value: f.Value, Err: err}
+			}
+			continue
+		}
+		if c.Extra == nil {
+			c.Extra = make(map[string]string)
+		}
+		c.Extra[f.Name] = f.Value
+	}
+	return stats, nil
+}
@@ -0,0 +1,90 @@
+package stat
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestParseTypedField(t *testing.T) {
+	f, err := parseTypedField(`S.4.1.73.addr.1:CGS:str:172.24.21.40:8080:"Server's address:port, shown only if show-legends is set, or at levels oper/admin for the CLI"`)
+	if err != nil {
+		t.Fatalf("unable to parse typed field: %v", err)
+	}
+	expected := TypedField{
+		ObjectType:  ObjectTypeServer,
+		ProxyID:     4,
+		ObjectID:    1,
+		Position:    73,
+		Name:        "addr",
+		Process:     1,
+		Origin:      OriginConfig,
+		Nature:      NatureGauge,
+		Scope:       ScopeService,
+		ValueType:   ValueTypeStr,
+		Value:       "172.24.21.40:8080",
+		Description: "Server's address:port, shown only if show-legends is set, or at levels oper/admin for the CLI",
+	}
+	if f != expected {
+		t.Fatalf("unexpected typed field: %+v", f)
+	}
+
+	f, err = parseTypedField("F.2.0.4.scur.1:MGP:u32:2")
+	if err != nil {
+		t.Fatalf("unable to parse typed field without description: %v", err)
+	}
+	if f.Value != "2" || f.Description != "" || f.Nature != NatureGauge {
+		t.Fatalf("unexpected typed field: %+v", f)
+	}
+}
+
+func TestParseShowStatTypedDesc(t *testing.T) {
+	response, err := os.ReadFile("../show_stat_typed_desc")
+	if err != nil {
+		t.Fatalf("unable to read sample: %v", err)
+	}
+
+	fields, err := ParseShowStatTyped(response)
+	if err != nil {
+		t.Fatalf("unable to parse typed stat: %v", err)
+	}
+	if len(fields) != 677 {
+		t.Fatalf("expected 677 fields got %d", len(fields))
+	}
+
+	stats, err := TypedToStatCounters(fields)
+	if err != nil {
+		t.Fatalf("unable to convert typed fields: %v", err)
+	}
+	if len(stats) != 9 {
+		t.Fatalf("expected 9 objects got %d", len(stats))
+	}
+
+	https := stats[2]
+	if https.PxName != "https-in" || https.SvName != "FRONTEND" || https.SSL.Sess != 19 || https.SSL.ReusedSess != 44 {
+		t.Fatalf("unexpected https-in frontend: %+v", https)
+	}
+
+	iws01 := stats[3]
+	if iws01.PxName != "indexws" || iws01.SvName != "iws01" || iws01.Type != ObjectTypeServer {
+		t.Fatalf("unexpected server: %+v", iws01)
+	}
+	if iws01.Stot != 7257 || iws01.Econ != 4 || iws01.CheckStatus != "L7OK" || iws01.Addr != "172.24.21.40:8080" {
+		t.Fatalf("unexpected server counters: %+v", iws01)
+	}
+}
+
+func TestParseShowStatTypedError(t *testing.T) {
+	response := "F.2.0.0.pxname.1:KNS:str:stats\nF.2.0:broken\n"
+
+	_, err := ParseShowStatTyped([]byte(response))
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 2 {
+		t.Fatalf("expected parse error on line 2 got: %v", err)
+	}
+
+	_, err = TypedToStatCounters([]TypedField{{Name: "scur", Value: "many"}})
+	if !errors.As(err, &perr) || perr.Column != "scur" {
+		t.Fatalf("expected parse error for scur got: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package state
+
+import "github.com/industria/haproxy-runtime-api-client/internal/parse"
+
+// ParseError reports a value in a response which could not be parsed
+type ParseError = parse.Error
+
+// ParseErrors are the errors of the lines left out when parsing leniently
+type ParseErrors = parse.Errors
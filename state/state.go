@@ -4,7 +4,9 @@ package state
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"strconv"
 )
 
@@ -79,79 +81,142 @@ type ServerState struct {
 	SrvAgentPort           string           // srv_agent_port:              Server health agent port.
 }
 
+// column names of the show servers state response in the order of the columns
+var columns = []string{"be_id", "be_name", "srv_id", "srv_name", "srv_addr", "srv_op_state", "srv_admin_state", "srv_uweight", "srv_iweight", "srv_time_since_last_change", "srv_check_status", "srv_check_result", "srv_check_health", "srv_check_state", "srv_agent_state", "bk_f_forced_id", "srv_f_forced_id", "srv_fqdn", "srv_port", "srvrecord", "srv_use_ssl", "srv_check_port", "srv_check_addr", "srv_agent_addr", "srv_agent_port"}
+
 // parse show servers state response as defined in http://docs.haproxy.org/2.6/management.html#9.3-show%20servers%20state
+// the first value which can not be parsed is returned as a *ParseError
 func ParseShowServersState(response []byte) ([]ServerState, error) {
-	// first line is version and must be 1 (49)
-	if response[0] != 49 {
-		return nil, fmt.Errorf("show servers state version was not 1")
-	}
-	// remove the first version line [49 10] 1 and line feed
-	response = response[2:]
+	return parseShowServersState(response, false)
+}
 
-	r := csv.NewReader(bytes.NewReader(response))
-	r.Comma = ' '   // white space separated colums in the runtime API responses
-	r.Comment = '#' // lines with # is comments in the runtime API responses
+// parse show servers state response like ParseShowServersState but continue past lines
+// which can not be parsed. The lines parsed are returned together with ParseErrors for the
+// lines left out, the error is nil when all lines were parsed
+func ParseShowServersStateLenient(response []byte) ([]ServerState, error) {
+	return parseShowServersState(response, true)
+}
 
-	all, err := r.ReadAll()
-	if err != nil {
-		return nil, err
+func parseShowServersState(response []byte, lenient bool) ([]ServerState, error) {
+	// first line is version and must be 1
+	version, response, _ := bytes.Cut(response, []byte("\n"))
+	if v := string(bytes.TrimSpace(version)); v != "1" {
+		return nil, &ParseError{Line: 1, Column: "version", Value: v, Err: errors.New("show servers state version was not 1")}
 	}
 
-	states := make([]ServerState, 0, len(all))
-	for _, row := range all {
-		states = append(states, stateElementsToServerState(row))
+	r := csv.NewReader(bytes.NewReader(response))
+	r.Comma = ' '          // white space separated colums in the runtime API responses
+	r.Comment = '#'        // lines with # is comments in the runtime API responses
+	r.FieldsPerRecord = -1 // the number of columns is checked when mapping the columns
+
+	var states []ServerState
+	var errs ParseErrors
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			perr := &ParseError{Err: err}
+			if cerr, ok := err.(*csv.ParseError); ok {
+				perr.Line = cerr.Line + 1
+			}
+			if !lenient {
+				return nil, perr
+			}
+			errs = append(errs, perr)
+			continue
+		}
+
+		line, _ := r.FieldPos(0)
+		state, err := stateElementsToServerState(row)
+		if err != nil {
+			perr := err.(*ParseError)
+			perr.Line = line + 1 // the version line was removed before reading
+			if !lenient {
+				return nil, perr
+			}
+			errs = append(errs, perr)
+			continue
+		}
+		states = append(states, state)
 	}
 
+	if len(errs) > 0 {
+		return states, errs
+	}
 	return states, nil
 }
 
-// map the elements from a response line to the ServerState struct. If the elements can not be mapped it will panic.
-func stateElementsToServerState(elements []string) ServerState {
-	return ServerState{
-		BeId:                   atoi(elements[0]),
+// map the elements from a response line to the ServerState struct.
+// If the elements can not be mapped a *ParseError without the line number is returned
+func stateElementsToServerState(elements []string) (ServerState, error) {
+	if len(elements) < len(columns) {
+		return ServerState{}, &ParseError{Err: fmt.Errorf("expected %d columns got %d", len(columns), len(elements))}
+	}
+
+	p := elementParser{elements: elements}
+	state := ServerState{
+		BeId:                   p.atoi(0),
 		BeName:                 elements[1],
-		SrvId:                  atoi(elements[2]),
+		SrvId:                  p.atoi(2),
 		SrvName:                elements[3],
 		SrvAddr:                elements[4],
-		SrvOpState:             OperationalState(atoi(elements[5])),
-		SrvAdminState:          AdminState(atoui(elements[6])),
-		SrvUWeight:             atoi(elements[7]),
-		SrvIWeight:             atoi(elements[8]),
-		SrvTimeSinceLastChange: atoi(elements[9]),
-		SrvCheckStatus:         atoi(elements[10]),
-		SrvCheckResult:         CheckResult(atoi(elements[11])),
-		SrvCheckHealth:         atoi(elements[12]),
-		SrvCheckState:          CheckState(atoui(elements[13])),
-		SrvAgentState:          CheckState(atoui(elements[14])),
-		BkFForcedId:            atob(elements[15]),
-		SrvFForcedId:           atob(elements[16]),
+		SrvOpState:             OperationalState(p.atoi(5)),
+		SrvAdminState:          AdminState(p.atoui(6)),
+		SrvUWeight:             p.atoi(7),
+		SrvIWeight:             p.atoi(8),
+		SrvTimeSinceLastChange: p.atoi(9),
+		SrvCheckStatus:         p.atoi(10),
+		SrvCheckResult:         CheckResult(p.atoi(11)),
+		SrvCheckHealth:         p.atoi(12),
+		SrvCheckState:          CheckState(p.atoui(13)),
+		SrvAgentState:          CheckState(p.atoui(14)),
+		BkFForcedId:            p.atob(15),
+		SrvFForcedId:           p.atob(16),
 		SrvFQDN:                elements[17],
 		SrvPort:                elements[18],
 		SrvRecord:              elements[19],
-		SrvUseSSL:              atob(elements[20]),
+		SrvUseSSL:              p.atob(20),
 		SrvCheckPort:           elements[21],
 		SrvCheckAddr:           elements[22],
 		SrvAgentAddr:           elements[23],
 		SrvAgentPort:           elements[24],
 	}
+	if p.err != nil {
+		return ServerState{}, p.err
+	}
+	return state, nil
+}
+
+// parser of the numeric elements of a line keeping the first error
+type elementParser struct {
+	elements []string
+	err      *ParseError
+}
+
+func (p *elementParser) fail(i int, err error) {
+	if p.err == nil {
+		p.err = &ParseError{Column: columns[i], Value: p.elements[i], Err: err}
+	}
 }
 
-func atoi(s string) int {
-	x, err := strconv.Atoi(s)
+func (p *elementParser) atoi(i int) int {
+	x, err := strconv.Atoi(p.elements[i])
 	if err != nil {
-		panic(err)
+		p.fail(i, err)
 	}
 	return x
 }
 
-func atoui(s string) uint {
-	x, err := strconv.ParseUint(s, 10, 64)
+func (p *elementParser) atoui(i int) uint {
+	x, err := strconv.ParseUint(p.elements[i], 10, 64)
 	if err != nil {
-		panic(err)
+		p.fail(i, err)
 	}
 	return uint(x)
 }
 
-func atob(s string) bool {
-	return atoi(s) != 0
+func (p *elementParser) atob(i int) bool {
+	return p.atoi(i) != 0
 }
@@ -1,10 +1,16 @@
 package state
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestStateElementsToServerState(t *testing.T) {
 	elements := []string{"4", "indexws", "1", "iws01", "172.24.21.40", "2", "0", "1", "1", "776", "15", "3", "4", "6", "0", "0", "0", "-", "8080", "-", "0", "0", "-", "-", "0"}
-	state := stateElementsToServerState(elements)
+	state, err := stateElementsToServerState(elements)
+	if err != nil {
+		t.Fatalf("unable to map elements : %v", err)
+	}
 	if state.BeId != 4 {
 		t.Fatalf("BeId not 4")
 	}
@@ -82,3 +88,44 @@ func TestStateElementsToServerState(t *testing.T) {
 		t.Fatalf("SrvAgentPort not 0")
 	}
 }
+
+func TestParseShowServersStateErrors(t *testing.T) {
+	tests := map[string]string{
+		"":                        "version",
+		"Can't find backend.\n\n": "version",
+		"2\n# be_id be_name\n\n":  "version",
+		"1\n# be_id\n4 indexws\n": "",
+		"1\n# be_id\nfour indexws 1 iws01 172.24.21.40 2 0 1 1 776 15 3 4 6 0 0 0 - 8080 - 0 0 - - 0\n": "be_id",
+	}
+	for response, column := range tests {
+		_, err := ParseShowServersState([]byte(response))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf("expected ParseError for %q got: %v", response, err)
+		}
+		if perr.Column != column {
+			t.Fatalf("expected column %q for %q got: %+v", column, response, perr)
+		}
+	}
+}
+
+func TestParseShowServersStateLenient(t *testing.T) {
+	response := "1\n" +
+		"# be_id be_name srv_id srv_name srv_addr srv_op_state srv_admin_state srv_uweight srv_iweight srv_time_since_last_change srv_check_status srv_check_result srv_check_health srv_check_state srv_agent_state bk_f_forced_id srv_f_forced_id srv_fqdn srv_port srvrecord srv_use_ssl srv_check_port srv_check_addr srv_agent_addr srv_agent_port\n" +
+		"4 indexws 1 iws01 172.24.21.40 2 0 1 1 776 15 3 4 6 0 0 0 - 8080 - 0 0 - - 0\n" +
+		"4 indexws 2 iws02 172.24.21.41 2 0 one 1 776 15 3 4 6 0 0 0 - 8080 - 0 0 - - 0\n" +
+		"4 indexws 3 iws03 172.24.21.42 2 0 1 1 776 15 3 4 6 0 0 0 - 8080 - 0 0 - - 0\n" +
+		"\n"
+
+	states, err := ParseShowServersStateLenient([]byte(response))
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected 1 parse error got: %v", err)
+	}
+	if errs[0].Line != 4 || errs[0].Column != "srv_uweight" || errs[0].Value != "one" {
+		t.Fatalf("unexpected parse error: %+v", errs[0])
+	}
+	if len(states) != 2 || states[0].SrvName != "iws01" || states[1].SrvName != "iws03" {
+		t.Fatalf("unexpected states: %+v", states)
+	}
+}
@@ -0,0 +1,57 @@
+package haproxy
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+)
+
+// replay the transcripts recorded from different HA-Proxy versions in testdata
+func TestReplayTranscripts(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatalf("unable to list transcripts: %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no transcripts in testdata")
+	}
+
+	for _, path := range paths {
+		transcript, err := haproxytest.LoadTranscript(path)
+		if err != nil {
+			t.Fatalf("unable to load %s: %v", path, err)
+		}
+
+		t.Run(transcript.Version, func(t *testing.T) {
+			client, err := NewClient("unix:///run/haproxy/admin.sock", WithDialer(haproxytest.NewReplayer(transcript)))
+			if err != nil {
+				t.Fatalf("unable to create client: %v", err)
+			}
+
+			stats, err := client.ShowStat()
+			if err != nil {
+				t.Fatalf("unable to parse show stat: %v", err)
+			}
+			if len(stats) == 0 {
+				t.Fatalf("no stat counters")
+			}
+			// the values reported by the version are known, empty values are reported for other object types
+			for _, c := range stats {
+				if !c.Status.Valid() || !c.Mode.Valid() || (c.Algo != "" && !c.Algo.Valid()) ||
+					(c.CheckStatus != "" && !c.CheckStatus.Valid()) || (c.AgentStatus != "" && !c.AgentStatus.Valid()) {
+					t.Fatalf("unknown value in %s/%s: status %q mode %q algo %q check %q agent %q",
+						c.PxName, c.SvName, c.Status, c.Mode, c.Algo, c.CheckStatus, c.AgentStatus)
+				}
+			}
+
+			states, err := client.ShowServersState()
+			if err != nil {
+				t.Fatalf("unable to parse show servers state: %v", err)
+			}
+			if len(states) == 0 {
+				t.Fatalf("no server states")
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package haproxy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Weight of a server, either absolute between 0 and 256 or a percentage of the initial
+// weight from the configuration. Backends using a static load balancing algorithm like
+// static-rr only accept the weights 0% and 100%
+type Weight struct {
+	Value   int
+	Percent bool // Value is a percentage of the initial weight
+}
+
+// an absolute weight
+func AbsoluteWeight(weight int) Weight {
+	return Weight{Value: weight}
+}
+
+// a weight relative to the initial weight of the server
+func PercentWeight(percent int) Weight {
+	return Weight{Value: percent, Percent: true}
+}
+
+// the weight as given to set weight, e.g. 10 or 50%
+func (w Weight) String() string {
+	if w.Percent {
+		return strconv.Itoa(w.Value) + "%"
+	}
+	return strconv.Itoa(w.Value)
+}
+
+// change the weight of a server using set weight <backend>/<server> <weight>[%]
+func (rc *RuntimeClient) SetServerWeight(backend, server string, weight Weight) error {
+	return rc.SetServerWeightContext(context.Background(), backend, server, weight)
+}
+
+// change the weight of a server bound to the context
+func (rc *RuntimeClient) SetServerWeightContext(ctx context.Context, backend, server string, weight Weight) error {
+	return setServerWeight(ctx, rc, backend, server, weight)
+}
+
+func setServerWeight(ctx context.Context, ex executor, backend, server string, weight Weight) error {
+	command := fmt.Sprintf("set weight %s/%s %s", backend, server, weight)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return err
+	}
+	return emptyResponse(command, resp)
+}
+
+// get the current and the initial weight of a server using get weight <backend>/<server>
+func (rc *RuntimeClient) GetWeight(backend, server string) (current, initial int, err error) {
+	return rc.GetWeightContext(context.Background(), backend, server)
+}
+
+// get the current and the initial weight of a server bound to the context
+func (rc *RuntimeClient) GetWeightContext(ctx context.Context, backend, server string) (current, initial int, err error) {
+	return getWeight(ctx, rc, backend, server)
+}
+
+// the response of get weight is in the form: 1 (initial 1)
+func getWeight(ctx context.Context, ex executor, backend, server string) (int, int, error) {
+	command := fmt.Sprintf("get weight %s/%s", backend, server)
+	resp, err := ex.ExecuteContext(ctx, command)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := responseError(command, resp); err != nil {
+		return 0, 0, err
+	}
+
+	var current, initial int
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(resp)), "%d (initial %d)", &current, &initial); err != nil {
+		return 0, 0, &CommandError{
+			Command:  command,
+			Response: strings.TrimSpace(string(resp)),
+			Err:      ErrUnexpectedResponse,
+		}
+	}
+	return current, initial, nil
+}
+
+// validate the weight returning an error for weights set weight does not accept
+func (w Weight) validate() error {
+	if w.Percent {
+		if w.Value < 0 {
+			return fmt.Errorf("weight percentage must not be negative: %s", w)
+		}
+		return nil
+	}
+	if w.Value < 0 || w.Value > 256 {
+		return fmt.Errorf("weight must be between 0 and 256: %s", w)
+	}
+	return nil
+}
+
+// change the weight of a server gradually from the start weight to the target in steps spread
+// over the duration. The first step is set immediately and the target is reached when the duration
+// has passed, e.g. 4 steps from 0% to 100% over a minute sets 25%, 50%, 75% and 100% at 20 seconds
+// apart. Used after a server returns from maintenance, so a cold server is not given its full share
+// of the traffic at once. HA-Proxy keeps the weight of a server through maintenance, so the ramp
+// starts from the weight given and not the current weight, which is usually the full weight. The
+// start and the target are both absolute or both percentages. The weights of the steps are rounded
+// towards the target so a target above zero never sets a weight of zero. The ramp stops when the
+// context ends leaving the weight of the last step set
+func (rc *RuntimeClient) RampWeight(ctx context.Context, backend, server string, start, target Weight, duration time.Duration, steps int) error {
+	if err := start.validate(); err != nil {
+		return err
+	}
+	if err := target.validate(); err != nil {
+		return err
+	}
+	if start.Percent != target.Percent {
+		return fmt.Errorf("ramp start and target must both be absolute or percentages: %s and %s", start, target)
+	}
+	if steps < 1 {
+		return fmt.Errorf("ramp steps must be at least 1: %d", steps)
+	}
+	if duration < 0 {
+		return fmt.Errorf("ramp duration must not be negative: %s", duration)
+	}
+
+	interval := time.Duration(0)
+	if steps > 1 {
+		interval = duration / time.Duration(steps-1)
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for step := 1; step <= steps; step++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		weight := target
+		weight.Value = rampStep(start.Value, target.Value, step, steps)
+		rc.logger.Debug("ramping weight", "backend", backend, "server", server, "weight", weight.String(), "step", step)
+		if err := rc.SetServerWeightContext(ctx, backend, server, weight); err != nil {
+			return err
+		}
+		timer.Reset(interval)
+	}
+	return nil
+}
+
+// the weight of a step between start and target rounded towards the target
+func rampStep(start, target, step, steps int) int {
+	if target >= start {
+		return start + ((target-start)*step+steps-1)/steps
+	}
+	return start - ((start-target)*step+steps-1)/steps
+}
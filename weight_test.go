@@ -0,0 +1,220 @@
+package haproxy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/industria/haproxy-runtime-api-client/haproxytest"
+)
+
+func TestWeightString(t *testing.T) {
+	if w := AbsoluteWeight(10).String(); w != "10" {
+		t.Fatalf("unexpected absolute weight: %s", w)
+	}
+	if w := PercentWeight(50).String(); w != "50%" {
+		t.Fatalf("unexpected percent weight: %s", w)
+	}
+}
+
+func TestSetServerWeight(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) {
+		s.Weight = 10
+		s.InitWeight = 10
+	})
+
+	tests := []struct {
+		weight  Weight
+		current int
+	}{
+		{AbsoluteWeight(20), 20},
+		{PercentWeight(50), 5},
+		{PercentWeight(0), 0},
+		{PercentWeight(100), 10},
+	}
+	for _, test := range tests {
+		if err := client.SetServerWeight("indexws", "iws01", test.weight); err != nil {
+			t.Fatalf("set weight %s failed: %v", test.weight, err)
+		}
+		current, initial, err := client.GetWeight("indexws", "iws01")
+		if err != nil {
+			t.Fatalf("get weight failed: %v", err)
+		}
+		if current != test.current || initial != 10 {
+			t.Fatalf("weight %s: unexpected %d (initial %d)", test.weight, current, initial)
+		}
+	}
+}
+
+func TestSetServerWeightInvalid(t *testing.T) {
+	_, client := newFakeHAProxy(t)
+
+	if err := client.SetServerWeight("indexws", "iws01", AbsoluteWeight(300)); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument got: %v", err)
+	}
+	if err := client.SetServerWeight("indexws", "iws01", PercentWeight(-10)); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected invalid argument got: %v", err)
+	}
+	if err := client.SetServerWeight("indexws", "nope", AbsoluteWeight(1)); !errors.Is(err, ErrUnknownServer) {
+		t.Fatalf("expected unknown server got: %v", err)
+	}
+	if _, _, err := client.GetWeight("indexws", "nope"); !errors.Is(err, ErrUnknownServer) {
+		t.Fatalf("expected unknown server got: %v", err)
+	}
+}
+
+func TestGetWeightUnexpectedResponse(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.Handle("get weight indexws/iws01", "weight unknown")
+
+	if _, _, err := client.GetWeight("indexws", "iws01"); !errors.Is(err, ErrUnexpectedResponse) {
+		t.Fatalf("expected unexpected response got: %v", err)
+	}
+}
+
+func TestRampWeight(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.InitWeight = 8 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := client.RampWeight(ctx, "indexws", "iws01", PercentWeight(0), PercentWeight(100), time.Millisecond*30, 4); err != nil {
+		t.Fatalf("ramp failed: %v", err)
+	}
+
+	var steps []string
+	for _, command := range srv.Commands() {
+		if strings.HasPrefix(command, "set weight") {
+			steps = append(steps, command)
+		}
+	}
+	expected := []string{
+		"set weight indexws/iws01 25%",
+		"set weight indexws/iws01 50%",
+		"set weight indexws/iws01 75%",
+		"set weight indexws/iws01 100%",
+	}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Fatalf("unexpected steps: %v", steps)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.Weight != 8 {
+		t.Fatalf("weight not ramped to 8: %d", fake.Weight)
+	}
+}
+
+// HA-Proxy keeps the weight through maintenance so the ramp does not start from the current weight
+func TestRampWeightAfterMaintenance(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) {
+		s.Weight = 8
+		s.InitWeight = 8
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := client.ServerMaintenance(ctx, "indexws", "iws01"); err != nil {
+		t.Fatalf("maintenance failed: %v", err)
+	}
+	if err := client.SetServerState("indexws", "iws01", ServerStateReady); err != nil {
+		t.Fatalf("ready failed: %v", err)
+	}
+	if current, _, err := client.GetWeight("indexws", "iws01"); err != nil || current != 8 {
+		t.Fatalf("weight not kept through maintenance: %d %v", current, err)
+	}
+
+	if err := client.RampWeight(ctx, "indexws", "iws01", AbsoluteWeight(0), AbsoluteWeight(8), 0, 4); err != nil {
+		t.Fatalf("ramp failed: %v", err)
+	}
+	var steps []string
+	for _, command := range srv.Commands() {
+		if strings.HasPrefix(command, "set weight") {
+			steps = append(steps, strings.TrimPrefix(command, "set weight indexws/iws01 "))
+		}
+	}
+	if !reflect.DeepEqual(steps, []string{"2", "4", "6", "8"}) {
+		t.Fatalf("unexpected steps: %v", steps)
+	}
+}
+
+func TestRampWeightRoundsUp(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	if err := client.RampWeight(context.Background(), "indexws", "iws01", AbsoluteWeight(0), AbsoluteWeight(2), 0, 3); err != nil {
+		t.Fatalf("ramp failed: %v", err)
+	}
+	var steps []string
+	for _, command := range srv.Commands() {
+		if strings.HasPrefix(command, "set weight") {
+			steps = append(steps, strings.TrimPrefix(command, "set weight indexws/iws01 "))
+		}
+	}
+	if !reflect.DeepEqual(steps, []string{"1", "2", "2"}) {
+		t.Fatalf("unexpected steps: %v", steps)
+	}
+}
+
+func TestRampWeightCancel(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err := client.RampWeight(ctx, "indexws", "iws01", PercentWeight(0), PercentWeight(100), time.Minute, 4)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded got: %v", err)
+	}
+	if fake, _ := srv.LookupServer("indexws", "iws01"); fake.Weight != 0 {
+		t.Fatalf("weight not left at the first step: %d", fake.Weight)
+	}
+}
+
+func TestRampWeightInvalid(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+
+	if err := client.RampWeight(context.Background(), "indexws", "iws01", PercentWeight(0), PercentWeight(100), time.Second, 0); err == nil {
+		t.Fatalf("expected error for zero steps")
+	}
+	if err := client.RampWeight(context.Background(), "indexws", "iws01", PercentWeight(0), PercentWeight(100), -time.Second, 2); err == nil {
+		t.Fatalf("expected error for negative duration")
+	}
+	for _, target := range []Weight{AbsoluteWeight(-1), AbsoluteWeight(257), PercentWeight(-10)} {
+		if err := client.RampWeight(context.Background(), "indexws", "iws01", Weight{Percent: target.Percent}, target, time.Second, 2); err == nil {
+			t.Fatalf("expected error for target %s", target)
+		}
+		if err := client.RampWeight(context.Background(), "indexws", "iws01", target, Weight{Percent: target.Percent}, time.Second, 2); err == nil {
+			t.Fatalf("expected error for start %s", target)
+		}
+	}
+	if err := client.RampWeight(context.Background(), "indexws", "iws01", AbsoluteWeight(0), PercentWeight(100), time.Second, 2); err == nil {
+		t.Fatalf("expected error for start and target in different units")
+	}
+	// the weights are validated before any command is sent
+	if commands := srv.Commands(); len(commands) != 0 {
+		t.Fatalf("unexpected commands: %v", commands)
+	}
+}
+
+func TestRampWeightDown(t *testing.T) {
+	srv, client := newFakeHAProxy(t)
+	srv.UpdateServer("indexws", "iws01", func(s *haproxytest.BackendServer) { s.InitWeight = 8 })
+
+	if err := client.RampWeight(context.Background(), "indexws", "iws01", PercentWeight(50), PercentWeight(100), 0, 2); err != nil {
+		t.Fatalf("ramp failed: %v", err)
+	}
+	if err := client.RampWeight(context.Background(), "indexws", "iws01", AbsoluteWeight(8), AbsoluteWeight(2), 0, 4); err != nil {
+		t.Fatalf("ramp failed: %v", err)
+	}
+
+	var steps []string
+	for _, command := range srv.Commands() {
+		if strings.HasPrefix(command, "set weight") {
+			steps = append(steps, strings.TrimPrefix(command, "set weight indexws/iws01 "))
+		}
+	}
+	if !reflect.DeepEqual(steps, []string{"75%", "100%", "6", "5", "3", "2"}) {
+		t.Fatalf("unexpected steps: %v", steps)
+	}
+}